@@ -6,10 +6,8 @@ package cmd
 import (
 	"crypto/rand"
 	"fmt"
-	"strings"
 	"time"
 
-	"github.com/lpreimesberger/plotlib/pkg/storageproof"
 	"github.com/spf13/cobra"
 )
 
@@ -18,23 +16,30 @@ var benchmarklookupCmd = &cobra.Command{
 	Use:   "benchmarklookup [paths]",
 	Short: "Benchmarks the lookup function.",
 	Long: `Benchmarks the lookup function by generating 1024 random hashes
-and looking them up in the plot files.`,
-	Args: cobra.ExactArgs(1),
+and looking them up in the plot files.
+Without paths, the configured plot directories are used.`,
+	Args: cobra.RangeArgs(0, 1),
 	Run: func(cmd *cobra.Command, args []string) {
-		paths := strings.Split(args[0], ",")
+		paths, err := plotPaths(firstArg(args))
+		if err != nil {
+			printError("%s", err)
+			return
+		}
 
-		pc, err := storageproof.LoadPlots(paths, false) // Don't need verbose output for loading
+		pc, err := loadPlots(paths, false) // Don't need verbose output for loading
 		if err != nil {
-			fmt.Printf("Error loading plots: %s\n", err)
+			printError("Error loading plots: %s", err)
 			return
 		}
 
 		if len(pc.Plots) == 0 {
-			fmt.Println("No plot files found.")
+			printError("No plot files found.")
 			return
 		}
 
-		fmt.Println("Benchmarking lookup function...")
+		if !jsonOutput() {
+			fmt.Println("Benchmarking lookup function...")
+		}
 
 		const numLookups = 1024
 		randomHashes := make([][]byte, numLookups)
@@ -43,26 +48,42 @@ and looking them up in the plot files.`,
 			_, _ = rand.Read(randomHashes[i])
 		}
 
+		result := benchmarkResult{Lookups: numLookups}
 		startTime := time.Now()
 
 		for i := 0; i < numLookups; i++ {
 			_, err := pc.LookUp(randomHashes[i])
 			if err != nil {
-				fmt.Printf("Error looking up hash: %s\n", err)
+				result.Errors++
+				if !jsonOutput() {
+					fmt.Printf("Error looking up hash: %s\n", err)
+				}
 				// We continue the benchmark even if one lookup fails
 			}
 		}
 
-		totalTime := time.Since(startTime)
-		avgTime := totalTime / numLookups
+		result.TotalTime = time.Since(startTime)
+		result.AverageTime = result.TotalTime / numLookups
 
-		fmt.Printf("\n--- Benchmark Results ---\n")
-		fmt.Printf("Total lookups: %d\n", numLookups)
-		fmt.Printf("Total time: %s\n", totalTime)
-		fmt.Printf("Average lookup time: %s\n", avgTime)
+		printResult(result)
 	},
 }
 
+// benchmarkResult holds the lookup benchmark timings.
+type benchmarkResult struct {
+	Lookups     int           `json:"lookups"`
+	Errors      int           `json:"errors"`
+	TotalTime   time.Duration `json:"total_ns"`
+	AverageTime time.Duration `json:"average_ns"`
+}
+
+func (r benchmarkResult) printText() {
+	fmt.Printf("\n--- Benchmark Results ---\n")
+	fmt.Printf("Total lookups: %d\n", r.Lookups)
+	fmt.Printf("Total time: %s\n", r.TotalTime)
+	fmt.Printf("Average lookup time: %s\n", r.AverageTime)
+}
+
 func init() {
 	rootCmd.AddCommand(benchmarklookupCmd)
 }
@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+	"github.com/spf13/cobra"
+)
+
+// cacheResult reports on the index cache.
+type cacheResult struct {
+	Dir       string        `json:"dir"`
+	Cleared   bool          `json:"cleared,omitempty"`
+	Plots     int           `json:"plots,omitempty"`
+	TotalKeys uint64        `json:"total_keys,omitempty"`
+	Duration  time.Duration `json:"duration_ns,omitempty"`
+}
+
+func (r cacheResult) printText() {
+	if r.Cleared {
+		fmt.Printf("Cleared the index cache in %s\n", r.Dir)
+		return
+	}
+	fmt.Printf("Indexed %d plot files with %d keys in %s in %s\n", r.Plots, r.TotalKeys, r.Dir, r.Duration.Round(time.Millisecond))
+}
+
+// cacheDir returns the configured index cache directory.
+func cacheDir() (string, error) {
+	if cfg.CacheDir == "" {
+		return "", errors.New("the index cache is disabled, set cache_dir in the config")
+	}
+	return cfg.CacheDir, nil
+}
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manages the plot index cache.",
+	Long: `Manages the plot index cache.
+When cache_dir is set in the config, loading plots keeps an index of each
+plot's header and key table there, so a plot whose size and modification time
+are unchanged is loaded without being read. The cache is disabled by default.`,
+}
+
+// cacheRebuildCmd represents the cache rebuild command
+var cacheRebuildCmd = &cobra.Command{
+	Use:   "rebuild [paths]",
+	Short: "Rereads plots and replaces their indexes.",
+	Long: `Rereads plots and replaces their indexes.
+The indexes of plots no longer found in the paths are removed. Without paths,
+the configured plot directories are indexed.`,
+	Args: cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := cacheDir()
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+		paths, err := plotPaths(firstArg(args))
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+
+		start := time.Now()
+		pc, err := loadPlotsWithOptions(paths, storageproof.LoadOptions{
+			CacheDir:     dir,
+			RebuildCache: true,
+			Verbose:      progress(),
+		})
+		if err != nil {
+			printError("Error loading plots: %s", err)
+			return
+		}
+		printResult(cacheResult{Dir: dir, Plots: len(pc.Plots), TotalKeys: pc.TotalKeys(), Duration: time.Since(start)})
+	},
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Removes every index from the cache.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := cacheDir()
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+		if err := storageproof.ClearCache(dir); err != nil {
+			printError("Error clearing the index cache: %s", err)
+			return
+		}
+		printResult(cacheResult{Dir: dir, Cleared: true})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheRebuildCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
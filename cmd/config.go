@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds settings shared by every command. It is read from a YAML file,
+// then overridden by PLOTLIB_* environment variables and finally by flags.
+type Config struct {
+	PlotDirs []string                `yaml:"plot_dirs" json:"plot_dirs"`
+	Workers  int                     `yaml:"workers" json:"workers"`
+	Hash     storageproof.HashParams `yaml:"hash" json:"hash"`
+	Output   string                  `yaml:"output" json:"output"`
+	// UnlockKeyFile and Listen configure the farming server and are not
+	// used by any command yet.
+	UnlockKeyFile string   `yaml:"unlock_key_file" json:"unlock_key_file"`
+	Listen        []string `yaml:"listen" json:"listen"`
+	// QueueFile is the state file of the plot queue.
+	QueueFile string `yaml:"queue_file" json:"queue_file"`
+	// CacheDir holds the index cache of loaded plots. Empty, the default,
+	// disables it.
+	CacheDir string `yaml:"cache_dir" json:"cache_dir"`
+
+	// File is the configuration file that was loaded, if any.
+	File string `yaml:"-" json:"file,omitempty"`
+}
+
+var (
+	configFile string
+	cfg        = defaultConfig()
+)
+
+func defaultConfig() Config {
+	return Config{
+		Hash:      storageproof.DefaultHashParams(),
+		Output:    outputText,
+		QueueFile: userConfigPath("queue.json"),
+	}
+}
+
+// userConfigPath returns the path of a file in the plotlib directory under
+// the user configuration directory, or "" if there is none.
+func userConfigPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "plotlib", name)
+}
+
+// loadConfig reads the configuration file and environment. A missing file is
+// only an error when it was asked for explicitly.
+func loadConfig(path string) (Config, error) {
+	c := defaultConfig()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("PLOTLIB_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = userConfigPath("config.yaml")
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &c); err != nil {
+				return c, fmt.Errorf("error reading config %s: %w", path, err)
+			}
+			c.File = path
+		case explicit || !errors.Is(err, fs.ErrNotExist):
+			return c, err
+		}
+	}
+
+	if err := c.applyEnv(); err != nil {
+		return c, err
+	}
+	if c.Hash.Algorithm != storageproof.HashArgon2id {
+		// Argon2 parameters merged in from the defaults don't apply
+		c.Hash.Time, c.Hash.Memory, c.Hash.Threads = 0, 0, 0
+	}
+	return c, nil
+}
+
+// applyEnv overrides the configuration with PLOTLIB_* environment variables.
+func (c *Config) applyEnv() error {
+	setters := []struct {
+		name string
+		set  func(string) error
+	}{
+		{"PLOTLIB_PLOT_DIRS", func(v string) error { c.PlotDirs = splitList(v); return nil }},
+		{"PLOTLIB_WORKERS", func(v string) (err error) { c.Workers, err = strconv.Atoi(v); return }},
+		{"PLOTLIB_HASH", func(v string) (err error) { c.Hash.Algorithm, err = storageproof.HashAlgorithmByName(v); return }},
+		{"PLOTLIB_HASH_SALT", func(v string) error { c.Hash.Salt = v; return nil }},
+		{"PLOTLIB_ARGON2_TIME", func(v string) error { return parseUint32(v, &c.Hash.Time) }},
+		{"PLOTLIB_ARGON2_MEMORY", func(v string) error { return parseUint32(v, &c.Hash.Memory) }},
+		{"PLOTLIB_ARGON2_THREADS", func(v string) error {
+			n, err := strconv.ParseUint(v, 10, 8)
+			c.Hash.Threads = uint8(n)
+			return err
+		}},
+		{"PLOTLIB_OUTPUT", func(v string) error { c.Output = v; return nil }},
+		{"PLOTLIB_UNLOCK_KEY_FILE", func(v string) error { c.UnlockKeyFile = v; return nil }},
+		{"PLOTLIB_LISTEN", func(v string) error { c.Listen = splitList(v); return nil }},
+		{"PLOTLIB_QUEUE_FILE", func(v string) error { c.QueueFile = v; return nil }},
+		{"PLOTLIB_CACHE_DIR", func(v string) error { c.CacheDir = v; return nil }},
+	}
+
+	for _, s := range setters {
+		if v, ok := os.LookupEnv(s.name); ok {
+			if err := s.set(v); err != nil {
+				return fmt.Errorf("invalid %s: %w", s.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseUint32(v string, dst *uint32) error {
+	n, err := strconv.ParseUint(v, 10, 32)
+	*dst = uint32(n)
+	return err
+}
+
+// splitList splits a comma-delimited list, dropping empty entries.
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// plotPaths returns the plot paths given as a comma-delimited argument, or the
+// configured plot directories when the argument is empty.
+func plotPaths(arg string) ([]string, error) {
+	if arg != "" {
+		return strings.Split(arg, ","), nil
+	}
+	if len(cfg.PlotDirs) == 0 {
+		return nil, errors.New("please provide a comma-delimited list of paths or set plot_dirs in the config")
+	}
+	return cfg.PlotDirs, nil
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// isHash reports whether arg looks like a hex challenge hash rather than a path.
+func isHash(arg string) bool {
+	b, err := hex.DecodeString(arg)
+	return err == nil && len(b) == 32
+}
+
+// configResult is the effective configuration.
+type configResult Config
+
+func (r configResult) printText() {
+	if r.File != "" {
+		fmt.Printf("# loaded from %s\n", r.File)
+	}
+	out, err := yaml.Marshal(Config(r))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Print(string(out))
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspects the configuration.",
+	Long: `Inspects the configuration.
+Settings are read from a YAML file, by default config.yaml in the plotlib
+directory under the user configuration directory, or the file named by
+--config or PLOTLIB_CONFIG. PLOTLIB_* environment variables override the
+file and flags override both.`,
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Prints the effective configuration.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		printResult(configResult(cfg))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd)
+}
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+	"github.com/spf13/cobra"
+)
+
+// dedupeResult lists the duplicate plots and keys of a farm.
+type dedupeResult struct {
+	Plots         int                          `json:"plots"`
+	Duplicates    []storageproof.DuplicatePlot `json:"duplicates"`
+	DuplicateKeys []duplicateKey               `json:"duplicate_keys"`
+}
+
+// duplicateKey is a key hash in more than one plot.
+type duplicateKey struct {
+	Hash  string   `json:"hash"`  // hex
+	Files []string `json:"files"` // of the plots holding it
+}
+
+func (r dedupeResult) printText() {
+	if len(r.Duplicates) == 0 && len(r.DuplicateKeys) == 0 {
+		fmt.Printf("No duplicates in %d plot files.\n", r.Plots)
+		return
+	}
+	for _, d := range r.Duplicates {
+		fmt.Printf("%s is a copy of %s\n", d.Path, d.Of)
+	}
+
+	// Keys are summarised by the plots sharing them
+	var groups []string
+	counts := make(map[string]int)
+	for _, k := range r.DuplicateKeys {
+		group := strings.Join(k.Files, ", ")
+		if counts[group] == 0 {
+			groups = append(groups, group)
+		}
+		counts[group]++
+	}
+	for _, group := range groups {
+		fmt.Printf("%d keys are in each of %s\n", counts[group], group)
+	}
+}
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe [paths]",
+	Short: "Lists duplicate plots and keys.",
+	Long: `Lists duplicate plots and keys.
+Lists plot files that are copies of other plots, which have the same plot ID,
+and key hashes found in more than one plot. Nothing is removed. Without paths,
+the configured plot directories are checked.`,
+	Args: cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		paths, err := plotPaths(firstArg(args))
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+
+		pc, err := loadPlots(paths, progress())
+		if err != nil {
+			printError("Error loading plots: %s", err)
+			return
+		}
+
+		r := dedupeResult{Plots: len(pc.Plots), Duplicates: pc.Duplicates, DuplicateKeys: []duplicateKey{}}
+		if r.Duplicates == nil {
+			r.Duplicates = []storageproof.DuplicatePlot{}
+		}
+		for _, dup := range pc.DuplicateKeys() {
+			k := duplicateKey{Hash: hex.EncodeToString(dup.Hash[:])}
+			for _, id := range dup.Plots {
+				k.Files = append(k.Files, pc.Plots[id].Path)
+			}
+			slices.Sort(k.Files)
+			r.DuplicateKeys = append(r.DuplicateKeys, k)
+		}
+		printResult(r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+}
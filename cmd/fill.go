@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+)
+
+// fillResult describes the plots created by plot --fill or --count.
+type fillResult struct {
+	Dir      string        `json:"dir"`
+	Planned  int           `json:"planned"`
+	Paths    []string      `json:"paths"`
+	DryRun   bool          `json:"dry_run,omitempty"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+func (r fillResult) printText() {
+	if r.DryRun {
+		fmt.Printf("%d plots fit in %s\n", r.Planned, r.Dir)
+		return
+	}
+	fmt.Printf("Created %d of %d plots in %s in %s\n", len(r.Paths), r.Planned, r.Dir, r.Duration.Round(time.Second))
+}
+
+// fill plots the --fill directory until it is full.
+func fill(kValue uint32, opts storageproof.PlotOptions) {
+	reserve, err := parseBytes(plotReserve)
+	if err != nil {
+		printError("%s", err)
+		return
+	}
+
+	planned, err := storageproof.FillCount(plotFill, kValue, reserve, opts)
+	if err != nil {
+		printError("Error planning plots: %s", err)
+		return
+	}
+	if plotDryRun {
+		printResult(fillResult{Dir: plotFill, Planned: planned, DryRun: true})
+		return
+	}
+	if planned == 0 {
+		printError("Error plotting to %s: %s", plotFill, storageproof.ErrInsufficientSpace)
+		return
+	}
+
+	plotSeries(plotFill, kValue, planned, reserve, opts)
+}
+
+// plotSeries creates count plots in destDir, as plot --fill or --count.
+func plotSeries(destDir string, kValue uint32, count int, reserve uint64, opts storageproof.PlotOptions) {
+	if plotParallel > 1 {
+		// Per-key progress lines of concurrent plots would overwrite each other
+		opts.Verbose = false
+	}
+	seriesOpts := storageproof.FillOptions{
+		PlotOptions: opts,
+		KValue:      kValue,
+		Reserve:     reserve,
+		Concurrency: plotParallel,
+	}
+	if progress() {
+		seriesOpts.Progress = printFillProgress
+	}
+
+	startTime := time.Now()
+	paths, err := storageproof.PlotSeries(destDir, count, seriesOpts)
+	if err != nil {
+		printError("Error plotting to %s after %d plots: %s", destDir, len(paths), err)
+		return
+	}
+	printResult(fillResult{Dir: destDir, Planned: count, Paths: paths, Duration: time.Since(startTime)})
+}
+
+// printFillProgress prints the start and end of each plot of a series.
+func printFillProgress(p storageproof.FillProgress) {
+	switch {
+	case !p.Done:
+		fmt.Printf("Starting plot %d of %d\n", p.Index, p.Total)
+	case p.Err != nil:
+		fmt.Printf("\nPlot %d of %d failed: %s\n", p.Index, p.Total, p.Err)
+	default:
+		fmt.Printf("\nPlot %d of %d done in %s: %s\n", p.Index, p.Total, p.Duration.Round(time.Second), p.Path)
+	}
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+	"github.com/spf13/cobra"
+)
+
+// plotInfo describes a plot file from its header.
+type plotInfo struct {
+	Path       string                  `json:"path"`
+	ID         string                  `json:"id,omitempty"` // absent in plots that predate IDs
+	Version    uint32                  `json:"version"`
+	NumKeys    uint32                  `json:"num_keys"`
+	LibVersion string                  `json:"lib_version"`
+	Format     storageproof.KeyFormat  `json:"format"`
+	Scheme     storageproof.SchemeID   `json:"scheme"`
+	HashParams storageproof.HashParams `json:"hash_params"`
+	FarmerKey  string                  `json:"farmer_key,omitempty"` // hex
+	PoolKey    string                  `json:"pool_key,omitempty"`   // hex
+	HeaderSize int                     `json:"header_size"`
+	FileSize   int64                   `json:"file_size"`
+	Metadata   storageproof.Metadata   `json:"metadata"`
+}
+
+// infoResult describes the plots given to the info command.
+type infoResult struct {
+	Plots []plotInfo `json:"plots"`
+}
+
+func (r infoResult) printText() {
+	for i, p := range r.Plots {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Plot:            %s\n", p.Path)
+		if p.ID != "" {
+			fmt.Printf("ID:              %s\n", p.ID)
+		}
+		fmt.Printf("Version:         %d (library %s)\n", p.Version, p.LibVersion)
+		fmt.Printf("Keys:            %d %s, %s\n", p.NumKeys, p.Scheme, p.Format)
+		fmt.Printf("Hash:            %s, salt %q", p.HashParams.Algorithm, p.HashParams.Salt)
+		if p.HashParams.Algorithm == storageproof.HashArgon2id {
+			fmt.Printf(", time %d, memory %d KiB, %d threads", p.HashParams.Time, p.HashParams.Memory, p.HashParams.Threads)
+		}
+		fmt.Println()
+		if p.FarmerKey != "" {
+			fmt.Printf("Farmer key:      %s\n", p.FarmerKey)
+		}
+		if p.PoolKey != "" {
+			fmt.Printf("Pool key:        %s\n", p.PoolKey)
+		}
+		fmt.Printf("Size:            %s (header %d bytes)\n", formatBytes(uint64(p.FileSize)), p.HeaderSize)
+
+		m := p.Metadata
+		if !m.CreatedAt.IsZero() {
+			fmt.Printf("Created:         %s\n", m.CreatedAt.Local().Format(time.RFC3339))
+		}
+		if m.Hostname != "" {
+			fmt.Printf("Host:            %s\n", m.Hostname)
+		}
+		if m.PlotterVersion != "" {
+			fmt.Printf("Plotter:         %s\n", m.PlotterVersion)
+		}
+		keys := make([]string, 0, len(m.Labels))
+		for k := range m.Labels {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			fmt.Printf("Label:           %s=%s\n", k, m.Labels[k])
+		}
+		if len(m.Unknown) != 0 {
+			fmt.Printf("Other metadata:  %d records\n", len(m.Unknown))
+		}
+	}
+}
+
+// infoCmd represents the info command
+var infoCmd = &cobra.Command{
+	Use:   "info [plot...]",
+	Short: "Prints the header and metadata of plot files.",
+	Long: `Prints the header and metadata of plot files.
+Only the headers are read. Plots may be local files or s3://bucket/key URLs.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var r infoResult
+		for _, path := range args {
+			store, name, err := pathStore(path)
+			if err != nil {
+				printError("%s", err)
+				return
+			}
+			h, err := storageproof.ReadPlotHeader(store, name)
+			if err != nil {
+				printError("Error reading plot %s: %s", path, err)
+				return
+			}
+			stat, err := store.Stat(name)
+			if err != nil {
+				printError("%s", err)
+				return
+			}
+
+			info := plotInfo{
+				Path:       path,
+				Version:    h.Version,
+				NumKeys:    h.NumKeys,
+				LibVersion: string(bytes.TrimRight(h.LibVersion[:], "\x00")),
+				Format:     h.Format,
+				Scheme:     h.Scheme,
+				HashParams: h.HashParams,
+				FarmerKey:  hex.EncodeToString(h.FarmerKey),
+				PoolKey:    hex.EncodeToString(h.PoolKey),
+				HeaderSize: h.Size(),
+				FileSize:   stat.Size(),
+				Metadata:   h.Metadata,
+			}
+			if h.ID != (storageproof.PlotID{}) {
+				info.ID = h.ID.String()
+			}
+			r.Plots = append(r.Plots, info)
+		}
+		printResult(r)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
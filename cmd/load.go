@@ -4,7 +4,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/lpreimesberger/plotlib/pkg/storageproof"
@@ -16,28 +19,148 @@ var loadCmd = &cobra.Command{
 	Use:   "load [paths]",
 	Short: "Loads plot files from a comma-delimited list of paths.",
 	Long: `Loads plot files from a comma-delimited list of paths.
-If a path is a directory, it will be searched recursively for plot files.`,
-	Args: cobra.ExactArgs(1),
+If a path is a directory, it will be searched recursively for plot files.
+Paths of the form s3://bucket/prefix load plots from S3-compatible object
+storage. Without paths, the configured plot directories are loaded.`,
+	Args: cobra.RangeArgs(0, 1),
 	Run: func(cmd *cobra.Command, args []string) {
-		paths := strings.Split(args[0], ",")
-
-		pc, err := storageproof.LoadPlots(paths, verbose)
+		paths, err := plotPaths(firstArg(args))
 		if err != nil {
-			fmt.Printf("Error loading plots: %s\n", err)
+			printError("%s", err)
 			return
 		}
 
-		if verbose {
-			fmt.Printf("Loaded %d plot files.\n", len(pc.Plots))
-			var totalKeys uint32
-			for _, plot := range pc.Plots {
-				totalKeys += plot.NumKeys
-			}
-			fmt.Printf("Total solutions: %d\n", totalKeys)
+		pc, err := loadPlots(paths, progress())
+		if err != nil {
+			printError("Error loading plots: %s", err)
+			return
 		}
+
+		printResult(newLoadResult(pc))
 	},
 }
 
+// loadPlots loads the plots in paths, which are local paths or
+// s3://bucket/prefix URLs, into one collection, through the configured index
+// cache. Object storage is reached with the standard AWS_* environment
+// variables, AWS_ENDPOINT_URL selecting an S3-compatible service other than
+// AWS.
+func loadPlots(paths []string, verbose bool) (*storageproof.PlotCollection, error) {
+	return loadPlotsWithOptions(paths, storageproof.LoadOptions{CacheDir: cfg.CacheDir, Verbose: verbose})
+}
+
+// loadPlotsWithOptions is loadPlots with opts applied to every store. Plots
+// that fail to load are reported on stderr and skipped; it fails only when
+// none load.
+func loadPlotsWithOptions(paths []string, opts storageproof.LoadOptions) (*storageproof.PlotCollection, error) {
+	var local []string
+	remote := make(map[string][]string) // prefixes by bucket
+	for _, path := range paths {
+		bucket, prefix, ok, err := parseS3Path(path)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			local = append(local, path)
+			continue
+		}
+		remote[bucket] = append(remote[bucket], prefix)
+	}
+
+	pc, err := storageproof.LoadPlotsWithOptions(local, opts)
+	errs := []error{err}
+	for bucket, prefixes := range remote {
+		opts.Store = s3Store(bucket)
+		bucketPlots, err := storageproof.LoadPlotsWithOptions(prefixes, opts)
+		errs = append(errs, err)
+		pc.Merge(bucketPlots)
+	}
+
+	err = errors.Join(errs...)
+	if err != nil && len(pc.Plots) == 0 {
+		return nil, err
+	}
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", line)
+		}
+	}
+	return pc, nil
+}
+
+// parseS3Path splits an s3://bucket/key URL, reporting false for local paths.
+func parseS3Path(path string) (bucket, key string, ok bool, err error) {
+	rest, ok := strings.CutPrefix(path, "s3://")
+	if !ok {
+		return "", "", false, nil
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", false, fmt.Errorf("invalid S3 path %s", path)
+	}
+	return bucket, key, true, nil
+}
+
+// pathStore returns the store holding path and its name there.
+func pathStore(path string) (storageproof.PlotStore, string, error) {
+	bucket, key, ok, err := parseS3Path(path)
+	if err != nil || !ok {
+		return storageproof.OSStore{}, path, err
+	}
+	return s3Store(bucket), key, nil
+}
+
+// s3Store returns the store of bucket, configured from the environment.
+func s3Store(bucket string) *storageproof.S3Store {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL")
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	return &storageproof.S3Store{
+		Endpoint:     endpoint,
+		Bucket:       bucket,
+		Region:       region,
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// loadResult summarises the loaded plot files.
+type loadResult struct {
+	Plots     int      `json:"plots"`
+	TotalKeys uint64   `json:"total_keys"`
+	Files     []string `json:"files"`
+	// Duplicates is the number of files skipped as copies of loaded plots.
+	Duplicates int `json:"duplicates,omitempty"`
+}
+
+func newLoadResult(pc *storageproof.PlotCollection) loadResult {
+	r := loadResult{Plots: len(pc.Plots), TotalKeys: pc.TotalKeys(), Files: []string{}, Duplicates: len(pc.Duplicates)}
+	for _, plot := range pc.Plots {
+		r.Files = append(r.Files, plot.Path)
+	}
+	sort.Strings(r.Files)
+	return r
+}
+
+func (r loadResult) printText() {
+	if verbose {
+		fmt.Printf("Loaded %d plot files.\n", r.Plots)
+		fmt.Printf("Total solutions: %d\n", r.TotalKeys)
+		if r.Duplicates != 0 {
+			fmt.Printf("Skipped %d duplicate plot files, see plotlib dedupe.\n", r.Duplicates)
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(loadCmd)
 }
@@ -0,0 +1,41 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+)
+
+func TestLoadPlotsSkipsFailures(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := storageproof.PlotWithOptions(dir, 1, storageproof.PlotOptions{
+		Format:     storageproof.FormatSeed,
+		Scheme:     storageproof.SchemeEd25519,
+		HashParams: &storageproof.HashParams{Algorithm: storageproof.HashSHAKE256, Salt: "plotlib test"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	bad := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bad, "sp2-corrupt.plot"), []byte("not a plot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The plots that load are kept despite the others failing
+	pc, err := loadPlotsWithOptions([]string{dir, bad, filepath.Join(dir, "missing")}, storageproof.LoadOptions{})
+	if err != nil || len(pc.Plots) != 1 {
+		t.Fatalf("Expected the one good plot, got %v", err)
+	}
+
+	// Failing when nothing loads
+	if _, err := loadPlotsWithOptions([]string{bad}, storageproof.LoadOptions{}); err == nil {
+		t.Errorf("Expected an error when no plot loads")
+	}
+	if pc, err := loadPlotsWithOptions([]string{t.TempDir()}, storageproof.LoadOptions{}); err != nil || len(pc.Plots) != 0 {
+		t.Errorf("Expected an empty directory to load no plots without error: %v", err)
+	}
+}
@@ -7,33 +7,43 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"strings"
 
 	"github.com/lpreimesberger/plotlib/pkg/storageproof"
 	"github.com/spf13/cobra"
 )
 
+var (
+	lookupMaxDistance int
+	lookupDifficulty  float64
+)
+
 // lookupCmd represents the lookup command
 var lookupCmd = &cobra.Command{
 	Use:   "lookup [paths] [hash]",
 	Short: "Looks up a hash in the plot files.",
 	Long: `Looks up a hash in the plot files.
-If a hash is not provided, it will run a test suite.`,
+If a hash is not provided, it will run a test suite. Without paths, the
+configured plot directories are used, so a single argument that is a 32-byte
+hex hash is looked up in them.`,
+	Args: cobra.RangeArgs(0, 2),
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) < 1 {
-			fmt.Println("Please provide a comma-delimited list of paths.")
+		if len(args) == 1 && len(cfg.PlotDirs) != 0 && isHash(args[0]) {
+			args = []string{"", args[0]}
+		}
+		paths, err := plotPaths(firstArg(args))
+		if err != nil {
+			printError("%s", err)
 			return
 		}
-		paths := strings.Split(args[0], ",")
 
-		pc, err := storageproof.LoadPlots(paths, true)
+		pc, err := loadPlots(paths, !jsonOutput())
 		if err != nil {
-			fmt.Printf("Error loading plots: %s\n", err)
+			printError("Error loading plots: %s", err)
 			return
 		}
 
 		if len(pc.Plots) == 0 {
-			fmt.Println("No plot files found.")
+			printError("No plot files found.")
 			return
 		}
 
@@ -41,68 +51,106 @@ If a hash is not provided, it will run a test suite.`,
 			// Lookup a specific hash
 			hash, err := hex.DecodeString(args[1])
 			if err != nil {
-				fmt.Printf("Invalid hash: %s\n", err)
+				printError("Invalid hash: %s", err)
 				return
 			}
 
-			solution, err := pc.LookUp(hash)
-			if err != nil {
-				fmt.Printf("Error looking up hash: %s\n", err)
-				return
+			opts := storageproof.LookUpOptions{}
+			if cmd.Flags().Changed("difficulty") {
+				target := storageproof.TargetForDifficulty(lookupDifficulty)
+				opts.Target = &target
+			}
+			if cmd.Flags().Changed("max-distance") {
+				opts.Target = &storageproof.Target{MaxDistance: lookupMaxDistance}
 			}
 
-			fmt.Printf("Best match: %s\n", solution.Hash)
-			fmt.Printf("Distance: %d\n", solution.Distance)
+			result := lookUp(pc, "", hash, opts)
+			if result.Error != "" {
+				printError("Error looking up hash: %s", result.Error)
+				return
+			}
+			printResult(result)
 		} else {
 			// Run test suite
-			fmt.Println("Running test suite...")
-
-			// Positive case
-			fmt.Println("\n--- Positive Case ---")
 			var knownHash []byte
 			for _, plot := range pc.Plots {
 				knownHash = plot.KeyEntries[0].Hash[:]
 				break
 			}
-			solution, err := pc.LookUp(knownHash)
-			if err != nil {
-				fmt.Printf("Error looking up hash: %s\n", err)
-			} else {
-				fmt.Printf("Looking up: %x\n", knownHash)
-				fmt.Printf("Best match: %s\n", solution.Hash)
-				fmt.Printf("Distance: %d\n", solution.Distance)
-			}
 
 			// Near miss case
-			fmt.Println("\n--- Near Miss Case ---")
 			nearMissHash := make([]byte, len(knownHash))
 			copy(nearMissHash, knownHash)
 			nearMissHash[0] ^= 0x01 // Flip a bit
-			solution, err = pc.LookUp(nearMissHash)
-			if err != nil {
-				fmt.Printf("Error looking up hash: %s\n", err)
-			} else {
-				fmt.Printf("Looking up: %x\n", nearMissHash)
-				fmt.Printf("Best match: %s\n", solution.Hash)
-				fmt.Printf("Distance: %d\n", solution.Distance)
-			}
 
 			// Complete miss case
-			fmt.Println("\n--- Complete Miss Case ---")
 			randomHash := make([]byte, 32)
 			_, _ = rand.Read(randomHash)
-			solution, err = pc.LookUp(randomHash)
-			if err != nil {
-				fmt.Printf("Error looking up hash: %s\n", err)
-			} else {
-				fmt.Printf("Looking up: %x\n", randomHash)
-				fmt.Printf("Best match: %s\n", solution.Hash)
-				fmt.Printf("Distance: %d\n", solution.Distance)
-			}
+
+			printResult(lookupSuiteResult{Cases: []lookupResult{
+				lookUp(pc, "Positive Case", knownHash, storageproof.LookUpOptions{}),
+				lookUp(pc, "Near Miss Case", nearMissHash, storageproof.LookUpOptions{}),
+				lookUp(pc, "Complete Miss Case", randomHash, storageproof.LookUpOptions{}),
+			}})
 		}
 	},
 }
 
+// lookupResult is the outcome of looking up one hash.
+type lookupResult struct {
+	Case     string                 `json:"case,omitempty"`
+	Hash     string                 `json:"hash"`
+	Solution *storageproof.Solution `json:"solution"` // nil when none meets the target
+	Quality  float64                `json:"quality,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+func lookUp(pc *storageproof.PlotCollection, name string, hash []byte, opts storageproof.LookUpOptions) lookupResult {
+	r := lookupResult{Case: name, Hash: hex.EncodeToString(hash)}
+	solution, err := pc.LookUpWithOptions(hash, opts)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Solution = solution
+	if solution != nil {
+		r.Quality = solution.Quality(pc.TotalKeys())
+	}
+	return r
+}
+
+func (r lookupResult) printText() {
+	if r.Solution == nil {
+		fmt.Println("No solution meets the target.")
+		return
+	}
+	fmt.Printf("Best match: %s\n", r.Solution.Hash)
+	fmt.Printf("Distance: %d\n", r.Solution.Distance)
+	fmt.Printf("Quality: %.2f bits\n", r.Quality)
+}
+
+// lookupSuiteResult is the outcome of the lookup test suite.
+type lookupSuiteResult struct {
+	Cases []lookupResult `json:"cases"`
+}
+
+func (r lookupSuiteResult) printText() {
+	fmt.Println("Running test suite...")
+	for _, c := range r.Cases {
+		fmt.Printf("\n--- %s ---\n", c.Case)
+		if c.Error != "" {
+			fmt.Printf("Error looking up hash: %s\n", c.Error)
+			continue
+		}
+		fmt.Printf("Looking up: %s\n", c.Hash)
+		fmt.Printf("Best match: %s\n", c.Solution.Hash)
+		fmt.Printf("Distance: %d\n", c.Solution.Distance)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(lookupCmd)
+	lookupCmd.Flags().IntVar(&lookupMaxDistance, "max-distance", 0, "only sign a solution within this distance of the hash")
+	lookupCmd.Flags().Float64Var(&lookupDifficulty, "difficulty", 0, "only sign a solution a single key reaches with probability at most 2^-difficulty")
+	lookupCmd.MarkFlagsMutuallyExclusive("difficulty", "max-distance")
 }
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Values of the --output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var outputFormat string
+
+// exitCode is the status the process exits with once the command returns.
+// printError sets it, so failures exit non-zero in every output mode.
+var exitCode int
+
+// textResult is a command result that can print itself for humans. In JSON
+// output mode it is marshalled instead.
+type textResult interface {
+	printText()
+}
+
+// errorResult reports a failed command in JSON output mode.
+type errorResult struct {
+	Error string `json:"error"`
+}
+
+func (r errorResult) printText() {
+	fmt.Println(r.Error)
+}
+
+func jsonOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// progress reports whether library progress messages may be printed, which
+// would otherwise corrupt JSON output.
+func progress() bool {
+	return verbose && !jsonOutput()
+}
+
+// printResult prints a command result in the selected output format.
+func printResult(result textResult) {
+	if !jsonOutput() {
+		result.printText()
+		return
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		out, _ = json.Marshal(errorResult{Error: err.Error()})
+	}
+	fmt.Println(string(out))
+}
+
+// printError prints a failure message, as an errorResult in JSON output mode,
+// and makes the command exit with status 1.
+func printError(format string, args ...any) {
+	if exitCode == 0 {
+		exitCode = 1
+	}
+	printResult(errorResult{Error: fmt.Sprintf(format, args...)})
+}
+
+func checkOutputFormat() error {
+	if outputFormat != outputText && outputFormat != outputJSON {
+		return fmt.Errorf("unknown output format %q, expected %s or %s", outputFormat, outputText, outputJSON)
+	}
+	return nil
+}
@@ -4,39 +4,265 @@
 package cmd
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/lpreimesberger/plotlib/pkg/storageproof"
 	"github.com/spf13/cobra"
 )
 
+var (
+	plotSeeds      bool
+	plotScheme     string
+	plotHash       string
+	plotHashParams = storageproof.DefaultHashParams()
+	plotFarmerKey  string
+	plotPoolKey    string
+	plotDryRun     bool
+	plotFill       string
+	plotReserve    string
+	plotParallel   int
+	plotTmp        string
+	plotDest       string
+	plotCount      int
+	plotPrealloc   bool
+	plotDirectIO   bool
+	plotLabels     map[string]string
+)
+
 // plotCmd represents the plot command
 var plotCmd = &cobra.Command{
 	Use:   "plot [kValue] [destDir]",
 	Short: "Generates a new plot file.",
 	Long: `Generates a new plot file with a given K value.
-The K value represents the number of keys to generate in thousands.`,
-	Args: cobra.ExactArgs(2),
+The K value represents the number of keys to generate in thousands.
+With --seeds only the 32-byte key seeds are stored, which makes the plot
+much smaller at the cost of expanding the winning key during lookup.
+With --fill the destination is given by the flag instead, and plots of the
+given K value are created until it is full.
+With --tmp plots are created in a temporary directory, such as a fast SSD,
+then moved to the destination, which may also be given by --dest. Copies
+across filesystems are verified against a checksum before they are renamed
+into place. With --count several plots are queued so the next one is created
+while the previous one is moved.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if plotFill != "" || plotDest != "" {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		kValue, err := strconv.Atoi(args[0])
+		kValue, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			printError("Invalid K value")
+			return
+		}
+
+		opts, err := plotOptionsFromFlags(cmd)
 		if err != nil {
-			fmt.Println("Invalid K value")
+			printError("%s", err)
+			return
+		}
+
+		if plotFill != "" {
+			fill(uint32(kValue), opts)
+			return
+		}
+		destDir := plotDest
+		if destDir == "" {
+			destDir = args[1]
+		}
+		if plotCount > 1 && !plotDryRun {
+			plotSeries(destDir, uint32(kValue), plotCount, 0, opts)
 			return
 		}
 
-		destDir := args[1]
+		if plotDryRun {
+			plan, err := storageproof.PlotPlan(destDir, uint32(kValue), opts)
+			if err != nil {
+				printError("Error planning plot: %s", err)
+				return
+			}
+			printResult(planResult{Plan: plan, DestDir: destDir})
+			return
+		}
 
-		err = storageproof.Plot(destDir, uint32(kValue), verbose)
+		startTime := time.Now()
+		path, err := storageproof.PlotWithOptions(destDir, uint32(kValue), opts)
 		if err != nil {
-			fmt.Printf("Error plotting: %s\n", err)
+			printError("Error plotting: %s", err)
 			return
 		}
 
-		fmt.Println("Plot file generated successfully!")
+		printResult(plotResult{
+			Path:     path,
+			NumKeys:  uint32(kValue) * 1000,
+			Format:   opts.Format,
+			Scheme:   opts.Scheme,
+			Duration: time.Since(startTime),
+		})
 	},
 }
 
+// plotResult describes a newly generated plot file.
+type plotResult struct {
+	Path     string                 `json:"path"`
+	NumKeys  uint32                 `json:"num_keys"`
+	Format   storageproof.KeyFormat `json:"format"`
+	Scheme   storageproof.SchemeID  `json:"scheme"`
+	Duration time.Duration          `json:"duration_ns"`
+}
+
+// planResult is the plan printed by --dry-run.
+type planResult struct {
+	*storageproof.Plan
+	DestDir string `json:"dest_dir"`
+}
+
+func (r planResult) printText() {
+	fmt.Printf("Keys:           %d\n", r.NumKeys)
+	fmt.Printf("File size:      %s (%d bytes)\n", formatBytes(r.FileSize), r.FileSize)
+	fmt.Printf("Estimated time: %s\n", r.EstimatedTime.Round(time.Second))
+	if r.FreeSpace != 0 {
+		fmt.Printf("Free space:     %s in %s\n", formatBytes(r.FreeSpace), r.DestDir)
+	}
+	if !r.Fits {
+		fmt.Println("The plot does not fit in the destination.")
+	}
+}
+
+// parseBytes parses a size such as 512, 10GiB or 1.5TB.
+func parseBytes(s string) (uint64, error) {
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+		{"B", 1},
+	}
+	number, scale := strings.TrimSpace(s), 1.0
+	for _, u := range units {
+		if strings.HasSuffix(strings.ToUpper(number), strings.ToUpper(u.suffix)) {
+			number, scale = strings.TrimSpace(number[:len(number)-len(u.suffix)]), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(n * scale), nil
+}
+
+// formatBytes formats a size with a binary unit, e.g. 1.5 GiB.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func (r plotResult) printText() {
+	if verbose {
+		fmt.Println()
+		fmt.Printf("Plot file: %s\n", r.Path)
+	}
+	fmt.Println("Plot file generated successfully!")
+}
+
+// plotOptionsFromFlags builds plot options from the configured hash
+// parameters, overridden by the plot command flags that were set.
+func plotOptionsFromFlags(cmd *cobra.Command) (storageproof.PlotOptions, error) {
+	scheme, err := storageproof.SchemeByName(plotScheme)
+	if err != nil {
+		return storageproof.PlotOptions{}, err
+	}
+
+	hashParams := cfg.Hash
+	if cmd.Flags().Changed("hash") {
+		hashParams.Algorithm, err = storageproof.HashAlgorithmByName(plotHash)
+		if err != nil {
+			return storageproof.PlotOptions{}, err
+		}
+		if hashParams.Algorithm == storageproof.HashArgon2id && cfg.Hash.Algorithm != storageproof.HashArgon2id {
+			// The configured hash has no argon2 parameters to keep
+			def := storageproof.DefaultHashParams()
+			hashParams.Time, hashParams.Memory, hashParams.Threads = def.Time, def.Memory, def.Threads
+		}
+	}
+	if cmd.Flags().Changed("argon2-time") {
+		hashParams.Time = plotHashParams.Time
+	}
+	if cmd.Flags().Changed("argon2-memory") {
+		hashParams.Memory = plotHashParams.Memory
+	}
+	if cmd.Flags().Changed("argon2-threads") {
+		hashParams.Threads = plotHashParams.Threads
+	}
+	if hashParams.Algorithm != storageproof.HashArgon2id {
+		// The argon2 flags don't apply, so don't record their defaults
+		hashParams.Time, hashParams.Memory, hashParams.Threads = 0, 0, 0
+	}
+
+	farmerKey, err := hex.DecodeString(plotFarmerKey)
+	if err != nil {
+		return storageproof.PlotOptions{}, fmt.Errorf("invalid farmer key: %w", err)
+	}
+	poolKey, err := hex.DecodeString(plotPoolKey)
+	if err != nil {
+		return storageproof.PlotOptions{}, fmt.Errorf("invalid pool key: %w", err)
+	}
+
+	opts := storageproof.PlotOptions{
+		TmpDir:      plotTmp,
+		Preallocate: plotPrealloc,
+		DirectIO:    plotDirectIO,
+		Scheme:      scheme,
+		HashParams:  &hashParams,
+		FarmerKey:   farmerKey,
+		PoolKey:     poolKey,
+		Labels:      plotLabels,
+		Verbose:     progress(),
+	}
+	if plotSeeds {
+		opts.Format = storageproof.FormatSeed
+	}
+	return opts, nil
+}
+
 func init() {
 	rootCmd.AddCommand(plotCmd)
+	addPlotFlags(plotCmd)
+	plotCmd.Flags().BoolVar(&plotDryRun, "dry-run", false, "print the plot size, estimated time and free space without plotting")
+	plotCmd.Flags().StringVar(&plotFill, "fill", "", "directory to fill with plots of kValue thousand keys")
+	plotCmd.Flags().StringVar(&plotReserve, "reserve", "0", "space to leave free with --fill, e.g. 10GiB")
+	plotCmd.Flags().IntVar(&plotParallel, "parallel", 1, "number of plots created at once with --fill or --count")
+	plotCmd.Flags().StringVar(&plotDest, "dest", "", "destination directory, instead of the destDir argument")
+	plotCmd.Flags().IntVar(&plotCount, "count", 1, "number of plots to create")
+}
+
+// addPlotFlags adds the flags read by plotOptionsFromFlags to cmd.
+func addPlotFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&plotSeeds, "seeds", false, "store key seeds instead of expanded private keys")
+	cmd.Flags().StringVar(&plotScheme, "scheme", "ml-dsa-87", "signature scheme: ml-dsa-87, ml-dsa-65, ml-dsa-44 or ed25519")
+	cmd.Flags().StringVar(&plotHash, "hash", "argon2id", "public key hash: argon2id, shake256, sha3-256 or blake2b-256")
+	cmd.Flags().Uint32Var(&plotHashParams.Time, "argon2-time", plotHashParams.Time, "argon2id time cost")
+	cmd.Flags().Uint32Var(&plotHashParams.Memory, "argon2-memory", plotHashParams.Memory, "argon2id memory cost in KiB")
+	cmd.Flags().Uint8Var(&plotHashParams.Threads, "argon2-threads", plotHashParams.Threads, "argon2id parallelism")
+	cmd.Flags().StringVar(&plotFarmerKey, "farmer-key", "", "hex farmer public key to bind the plot to")
+	cmd.Flags().StringVar(&plotPoolKey, "pool-key", "", "hex pool public key to bind the plot to, requires --farmer-key")
+	cmd.Flags().StringVar(&plotTmp, "tmp", "", "directory to create plots in before moving them to the destination")
+	cmd.Flags().BoolVar(&plotPrealloc, "preallocate", false, "reserve the whole plot file before writing it")
+	cmd.Flags().BoolVar(&plotDirectIO, "direct-io", false, "write key data with O_DIRECT, bypassing the page cache (Linux only)")
+	cmd.Flags().StringToStringVar(&plotLabels, "label", nil, "label to record in the plot metadata as key=value, may be repeated")
 }
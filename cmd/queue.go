@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queueFile     string
+	queueCount    int
+	queueParallel int
+)
+
+// queueCmd represents the queue command
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manages a queue of plot jobs.",
+	Long: `Manages a queue of plot jobs.
+Jobs are kept in a state file, by default queue.json next to the config file,
+and run by "queue run". If the runner stops before a job finishes, the job is
+restarted from scratch by the next runner.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := rootCmd.PersistentPreRunE(cmd, args); err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("queue-file") {
+			queueFile = cfg.QueueFile
+		}
+		if queueFile == "" {
+			return errors.New("no queue file, set --queue-file or queue_file in the config")
+		}
+		return nil
+	},
+}
+
+// queueAddCmd represents the queue add command
+var queueAddCmd = &cobra.Command{
+	Use:   "add [kValue] [destDir]",
+	Short: "Adds plot jobs to the queue.",
+	Long: `Adds plot jobs to the queue.
+Takes the same plot options as the plot command. With --count several
+identical jobs are added.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		kValue, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			printError("Invalid K value")
+			return
+		}
+		opts, err := plotOptionsFromFlags(cmd)
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+		// Catch bad options now rather than when the job runs
+		if kValue == 0 || kValue > storageproof.MaxKValue {
+			printError("K value must be between 1 and %d", storageproof.MaxKValue)
+			return
+		}
+		if err := opts.HashParams.Validate(); err != nil {
+			printError("%s", err)
+			return
+		}
+		if len(opts.FarmerKey) == 0 && len(opts.PoolKey) != 0 {
+			printError("--pool-key requires --farmer-key")
+			return
+		}
+
+		destDir, err := filepath.Abs(args[1])
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+		tmpDir := opts.TmpDir
+		if tmpDir != "" {
+			if tmpDir, err = filepath.Abs(tmpDir); err != nil {
+				printError("%s", err)
+				return
+			}
+		}
+
+		added, err := storageproof.NewPlotQueue(queueFile).Add(storageproof.PlotJob{
+			KValue:      uint32(kValue),
+			DestDir:     destDir,
+			TmpDir:      tmpDir,
+			Format:      opts.Format,
+			Scheme:      opts.Scheme,
+			HashParams:  *opts.HashParams,
+			FarmerKey:   hex.EncodeToString(opts.FarmerKey),
+			PoolKey:     hex.EncodeToString(opts.PoolKey),
+			Preallocate: opts.Preallocate,
+			DirectIO:    opts.DirectIO,
+			Labels:      opts.Labels,
+		}, queueCount)
+		if err != nil {
+			printError("Error adding jobs: %s", err)
+			return
+		}
+		printResult(queueResult{Jobs: added})
+	},
+}
+
+// queueListCmd represents the queue list command
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the jobs in the queue.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		jobs, err := storageproof.NewPlotQueue(queueFile).Jobs()
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+		printResult(queueResult{Jobs: jobs, list: true})
+	},
+}
+
+// queueCancelCmd represents the queue cancel command
+var queueCancelCmd = &cobra.Command{
+	Use:   "cancel [id...]",
+	Short: "Cancels queued or running jobs.",
+	Long: `Cancels queued or running jobs.
+A running job can't be interrupted, so its plot is removed once it finishes.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				printError("Invalid job id %q", arg)
+				return
+			}
+			ids[i] = id
+		}
+		canceled, err := storageproof.NewPlotQueue(queueFile).Cancel(ids...)
+		if err != nil {
+			printError("Error canceling jobs: %s", err)
+			return
+		}
+		printResult(queueResult{Jobs: canceled})
+	},
+}
+
+// queueRunCmd represents the queue run command
+var queueRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Runs the queued jobs.",
+	Long: `Runs the queued jobs until none are left.
+Only one runner may use a queue at a time. Jobs left running by a runner that
+stopped are restarted.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		q := storageproof.NewPlotQueue(queueFile)
+		if err := runQueue(q, queueParallel); err != nil {
+			printError("Error running queue: %s", err)
+			return
+		}
+		jobs, err := q.Jobs()
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+		printResult(queueResult{Jobs: jobs, list: true})
+	},
+}
+
+// runQueue runs queued jobs, parallel at a time, until none are left.
+func runQueue(q *storageproof.PlotQueue, parallel int) error {
+	pid := os.Getpid()
+	interrupted, err := q.Acquire(pid)
+	if err != nil {
+		return err
+	}
+	if progress() {
+		for _, job := range interrupted {
+			fmt.Printf("Restarting interrupted job %d\n", job.ID)
+		}
+	}
+
+	// Release the queue on Ctrl-C so the next runner restarts the jobs at once
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(storageproof.RunnerHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = q.Heartbeat(pid)
+			case <-signals:
+				_ = q.Release()
+				os.Exit(130)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < max(parallel, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, err := q.Claim()
+				if err != nil {
+					printError("%s", err)
+					return
+				}
+				if job == nil {
+					return
+				}
+				runJob(q, job, parallel <= 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return q.Release()
+}
+
+// runJob runs a claimed job, printing its progress.
+func runJob(q *storageproof.PlotQueue, job *storageproof.PlotJob, verbose bool) {
+	if progress() {
+		fmt.Printf("Starting job %d: %d thousand keys to %s\n", job.ID, job.KValue, job.DestDir)
+	}
+	recorded, err := q.RunJob(job, verbose && progress())
+	if err != nil {
+		printError("Error recording job %d: %s", job.ID, err)
+		return
+	}
+	if progress() {
+		fmt.Println()
+		printJob(recorded)
+	}
+}
+
+// queueResult lists jobs.
+type queueResult struct {
+	Jobs []*storageproof.PlotJob `json:"jobs"`
+	list bool
+}
+
+func (r queueResult) printText() {
+	if r.list && len(r.Jobs) == 0 {
+		fmt.Println("The queue is empty.")
+	}
+	for _, job := range r.Jobs {
+		printJob(job)
+	}
+}
+
+// printJob prints the state of a job on one line.
+func printJob(j *storageproof.PlotJob) {
+	fmt.Printf("Job %d: %s, %d thousand keys to %s", j.ID, j.Status, j.KValue, j.DestDir)
+	if j.Duration != 0 {
+		fmt.Printf(" in %s", j.Duration.Round(time.Second))
+	}
+	switch {
+	case j.Error != "":
+		fmt.Printf(": %s", j.Error)
+	case j.Path != "":
+		fmt.Printf(": %s", j.Path)
+	}
+	fmt.Println()
+}
+
+func init() {
+	rootCmd.AddCommand(queueCmd)
+	queueCmd.PersistentFlags().StringVar(&queueFile, "queue-file", "", "queue state file, defaults to queue_file in the config")
+
+	queueCmd.AddCommand(queueAddCmd)
+	addPlotFlags(queueAddCmd)
+	queueAddCmd.Flags().IntVar(&queueCount, "count", 1, "number of identical jobs to add")
+
+	queueCmd.AddCommand(queueListCmd)
+	queueCmd.AddCommand(queueCancelCmd)
+
+	queueCmd.AddCommand(queueRunCmd)
+	queueRunCmd.Flags().IntVar(&queueParallel, "parallel", 1, "number of jobs run at once")
+}
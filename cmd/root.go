@@ -19,6 +19,20 @@ Use to make and check Shadow plots on your local disk.  All code is Apache-2.0 l
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+		cfg, err = loadConfig(configFile)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if cmd.Flags().Changed("output") {
+			cfg.Output = outputFormat
+		} else {
+			outputFormat = cfg.Output
+		}
+		return checkOutputFormat()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -29,8 +43,11 @@ func Execute() {
 	if err != nil {
 		os.Exit(1)
 	}
+	os.Exit(exitCode)
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "output format: text or json")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is plotlib/config.yaml in the user config directory)")
 }
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lpreimesberger/plotlib/pkg/storageproof"
+	"github.com/spf13/cobra"
+)
+
+var statsSamples int
+
+// statsResult wraps the farm statistics for printing.
+type statsResult struct {
+	*storageproof.FarmStats
+}
+
+func (r statsResult) printText() {
+	fmt.Printf("Plots:                  %d\n", r.Plots)
+	fmt.Printf("Keys:                   %d\n", r.TotalKeys)
+	fmt.Printf("Size:                   %s\n", formatBytes(r.TotalBytes))
+	for _, d := range r.Dirs {
+		fmt.Printf("  %s: %d plots, %d keys, %s\n", d.Path, d.Plots, d.Keys, formatBytes(d.Bytes))
+	}
+	if len(r.Devices) > 1 {
+		fmt.Println("Devices:")
+		for _, d := range r.Devices {
+			fmt.Printf("  %s (%s): %d plots, %d keys, %s\n", d.Device, d.Path, d.Plots, d.Keys, formatBytes(d.Bytes))
+		}
+	}
+	fmt.Printf("Expected best distance: %.2f\n", r.ExpectedBestDistance)
+	if r.Samples == 0 {
+		return
+	}
+	fmt.Printf("Sampled best distance:  %.2f over %d random challenges\n", r.SampledBestDistance, r.Samples)
+
+	peak := 0
+	for _, bin := range r.Histogram {
+		peak = max(peak, bin.Count)
+	}
+	for _, bin := range r.Histogram {
+		bar := strings.Repeat("#", (bin.Count*40+peak-1)/peak)
+		fmt.Printf("  %3d %-40s %d\n", bin.Distance, bar, bin.Count)
+	}
+}
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats [paths]",
+	Short: "Prints statistics of the farm.",
+	Long: `Prints statistics of the farm: its plots, keys and bytes by directory and
+device, the best distance a farm of its size finds on average, and a histogram
+of the best distances found for random challenges.
+Without paths, the configured plot directories are used.`,
+	Args: cobra.RangeArgs(0, 1),
+	Run: func(cmd *cobra.Command, args []string) {
+		paths, err := plotPaths(firstArg(args))
+		if err != nil {
+			printError("%s", err)
+			return
+		}
+
+		pc, err := loadPlots(paths, progress())
+		if err != nil {
+			printError("Error loading plots: %s", err)
+			return
+		}
+
+		stats, err := pc.StatsWithOptions(storageproof.StatsOptions{Samples: statsSamples})
+		if err != nil {
+			printError("Error sampling lookups: %s", err)
+			return
+		}
+		printResult(statsResult{stats})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().IntVar(&statsSamples, "samples", 100, "number of random challenges to look up for the histogram")
+}
@@ -4,43 +4,259 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/lpreimesberger/plotlib/pkg/storageproof"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes of the verify command. Cobra already exits with 1 on usage errors.
+const (
+	exitInvalid   = 2
+	exitMalformed = 3
+	exitIOError   = 4
+)
+
+// verifyBatchSize is how many streamed solutions are verified at once.
+const verifyBatchSize = 256
+
+var (
+	verifyAllowWeakHash bool
+	verifyFarmerKey     string
+	verifyPlotIDs       []string
+	verifyFile          string
+	verifyWorkers       int
+	verifyJSON          bool
+)
+
+// verifyResult is the outcome of verifying one solution.
+type verifyResult struct {
+	Line   int    `json:"line,omitempty"`
+	Status string `json:"status"` // valid, invalid or malformed
+	Reason string `json:"reason,omitempty"`
+}
+
 // verifyCmd represents the verify command
 var verifyCmd = &cobra.Command{
-	Use:   "verify [solution]",
+	Use:   "verify [solution|file|-]",
 	Short: "Verifies a storage proof solution.",
-	Long:  `Verifies a storage proof solution provided as a JSON string.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Verifies storage proof solutions.
+The argument is a JSON solution, a file holding one JSON solution or
+newline-delimited JSON solutions, or - for standard input. Standard input is
+also read when there is no argument. Solutions are verified concurrently.
+
+Exits with 2 if any solution is invalid, 3 if any solution is malformed and
+4 if the input can't be read, the highest applicable code winning.`,
+	Args: cobra.RangeArgs(0, 1),
 	Run: func(cmd *cobra.Command, args []string) {
-		solutionJSON := args[0]
+		if verifyJSON {
+			outputFormat = outputJSON
+		}
+		if !cmd.Flags().Changed("workers") {
+			verifyWorkers = cfg.Workers
+		}
 
-		var solution storageproof.Solution
-		err := json.Unmarshal([]byte(solutionJSON), &solution)
-		if err != nil {
-			fmt.Printf("Error unmarshalling solution: %s\n", err)
-			return
+		opts := storageproof.VerifyOptions{}
+		if verifyFarmerKey != "" {
+			var err error
+			opts.FarmerKey, err = hex.DecodeString(verifyFarmerKey)
+			if err != nil {
+				printError("Invalid farmer key: %s", err)
+				os.Exit(1)
+			}
+		}
+		if verifyAllowWeakHash {
+			opts.HashPolicy = storageproof.PermissiveHashPolicy
+		}
+		if len(verifyPlotIDs) != 0 {
+			registered := make(map[storageproof.PlotID]bool)
+			for _, s := range verifyPlotIDs {
+				var id storageproof.PlotID
+				if err := id.UnmarshalText([]byte(s)); err != nil {
+					printError("Invalid plot ID: %s", err)
+					os.Exit(1)
+				}
+				registered[id] = true
+			}
+			opts.PlotRegistered = func(id storageproof.PlotID) bool { return registered[id] }
 		}
 
-		valid, err := solution.Verify()
-		if err != nil {
-			fmt.Printf("Error verifying solution: %s\n", err)
-			return
+		source := "-"
+		if verifyFile != "" {
+			source = verifyFile
+		} else if len(args) == 1 {
+			source = args[0]
 		}
 
-		if valid {
-			fmt.Println("Solution is valid")
-		} else {
-			fmt.Println("Solution is invalid")
+		v := &solutionVerifier{opts: opts}
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(source), "{"):
+			v.add(0, []byte(source))
+		case source == "-":
+			v.read(os.Stdin)
+		default:
+			file, err := os.Open(source)
+			if err != nil {
+				printError("Error opening solutions: %s", err)
+				os.Exit(exitIOError)
+			}
+			v.read(file)
+			_ = file.Close()
+		}
+		v.flush()
+
+		if v.code != 0 {
+			os.Exit(v.code)
 		}
 	},
 }
 
+// solutionVerifier verifies solutions in batches, printing results in input
+// order and tracking the exit code.
+type solutionVerifier struct {
+	opts      storageproof.VerifyOptions
+	solutions []*storageproof.Solution
+	lines     []int
+	code      int
+}
+
+// read verifies newline-delimited solutions from r, or a single solution
+// spread over several lines.
+func (v *solutionVerifier) read(r io.Reader) {
+	if err := splitSolutions(r, v.add); err != nil {
+		v.flush()
+		printError("Error reading solutions: %s", err)
+		v.code = exitIOError
+	}
+}
+
+// splitSolutions calls add with each line of newline-delimited JSON from r,
+// or once with line zero and all of r when its first line isn't a complete
+// JSON value, as when a solution is spread over several lines.
+func splitSolutions(r io.Reader, add func(line int, data []byte)) error {
+	reader := bufio.NewReader(r)
+	var document []byte
+	first, ndjson := true, true
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		trimmed := bytes.TrimSpace(data)
+		switch {
+		case !ndjson:
+			document = append(document, data...)
+		case len(trimmed) == 0:
+		case first && incompleteJSON(trimmed):
+			ndjson = false
+			document = append(document, data...)
+		default:
+			add(line, trimmed)
+		}
+		if len(trimmed) != 0 {
+			first = false
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	if !ndjson {
+		add(0, document)
+	}
+	return nil
+}
+
+// incompleteJSON reports whether data ends before the JSON value it starts
+// is complete. A value that is complete but malformed, or followed by more
+// data, is not incomplete.
+func incompleteJSON(data []byte) bool {
+	var value json.RawMessage
+	err := json.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// add queues a JSON solution found on line, zero when it isn't from a stream.
+func (v *solutionVerifier) add(line int, data []byte) {
+	var solution storageproof.Solution
+	if err := json.Unmarshal(data, &solution); err != nil {
+		// Keep results in input order by verifying everything queued before it
+		v.flush()
+		v.report(verifyResult{Line: line, Status: "malformed", Reason: err.Error()})
+		return
+	}
+	v.solutions = append(v.solutions, &solution)
+	v.lines = append(v.lines, line)
+	if len(v.solutions) == verifyBatchSize {
+		v.flush()
+	}
+}
+
+// flush verifies the queued solutions.
+func (v *solutionVerifier) flush() {
+	results := storageproof.VerifyBatchWithOptions(v.solutions, v.opts, verifyWorkers)
+	for i, result := range results {
+		vr := verifyResult{Line: v.lines[i], Status: "valid"}
+		switch {
+		case errors.Is(result.Err, storageproof.ErrMalformedSolution):
+			vr.Status, vr.Reason = "malformed", result.Err.Error()
+		case result.Err != nil:
+			vr.Status, vr.Reason = "invalid", result.Err.Error()
+		case !result.Valid:
+			vr.Status, vr.Reason = "invalid", "signature, plot hash or plot does not match"
+		}
+		v.report(vr)
+	}
+	v.solutions, v.lines = v.solutions[:0], v.lines[:0]
+}
+
+// report prints a result and raises the exit code to match it.
+func (v *solutionVerifier) report(vr verifyResult) {
+	v.code = max(v.code, printVerifyResult(vr))
+}
+
+func (vr verifyResult) printText() {
+	prefix := "Solution"
+	if vr.Line != 0 {
+		prefix = fmt.Sprintf("Line %d: solution", vr.Line)
+	}
+	if vr.Reason != "" {
+		fmt.Printf("%s is %s: %s\n", prefix, vr.Status, vr.Reason)
+	} else {
+		fmt.Printf("%s is %s\n", prefix, vr.Status)
+	}
+}
+
+// printVerifyResult prints a result, one JSON object per line in JSON output
+// mode, and returns its exit code.
+func printVerifyResult(vr verifyResult) int {
+	printResult(vr)
+
+	switch vr.Status {
+	case "invalid":
+		return exitInvalid
+	case "malformed":
+		return exitMalformed
+	}
+	return 0
+}
+
 func init() {
 	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().StringVar(&verifyFarmerKey, "farmer-key", "", "hex farmer public key the solution's plot must be bound to")
+	verifyCmd.Flags().StringSliceVar(&verifyPlotIDs, "plot-id", nil, "hex ID of a registered plot; solutions must prove their key is in one")
+	verifyCmd.Flags().BoolVar(&verifyAllowWeakHash, "allow-weak-hash", false, "accept any valid hash parameters, e.g. for test networks")
+	verifyCmd.Flags().StringVar(&verifyFile, "file", "", "file of solutions to verify, the same as passing it as the argument")
+	verifyCmd.Flags().IntVar(&verifyWorkers, "workers", 0, "number of concurrent verifications, defaults to one per CPU")
+	verifyCmd.Flags().BoolVar(&verifyJSON, "json", false, "print one JSON result per solution")
+	_ = verifyCmd.Flags().MarkDeprecated("json", "use --output json instead")
 }
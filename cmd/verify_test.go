@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitSolutions(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		input string
+		lines []int
+	}{
+		{"ndjson", "{\"a\": 1}\n\n{\"b\": 2}\n", []int{1, 3}},
+		{"bad first line", "{\"a\": x}\n{\"b\": 2}\n{\"c\": 3}", []int{1, 2, 3}},
+		{"trailing data", "{\"a\": 1} {\n{\"b\": 2}\n", []int{1, 2}},
+		{"not json", "solution\n{\"b\": 2}\n", []int{1, 2}},
+		{"document", "{\n  \"a\": 1\n}\n", []int{0}},
+		{"truncated document", "{\"a\":\n", []int{0}},
+	} {
+		var lines []int
+		var data []string
+		err := splitSolutions(strings.NewReader(c.input), func(line int, d []byte) {
+			lines = append(lines, line)
+			data = append(data, string(d))
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !slices.Equal(lines, c.lines) {
+			t.Errorf("%s: got solutions on lines %v, want %v: %q", c.name, lines, c.lines, data)
+		}
+	}
+}
@@ -6,13 +6,16 @@ toolchain go1.24.5
 
 require (
 	github.com/cloudflare/circl v1.6.1
+	github.com/fxamacker/cbor/v2 v2.9.0
 	github.com/google/uuid v1.6.0
 	github.com/spf13/cobra v1.10.1
 	golang.org/x/crypto v0.42.0
+	golang.org/x/sys v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
-	golang.org/x/sys v0.36.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 )
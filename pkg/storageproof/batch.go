@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// VerifyResult is the outcome of verifying one solution of a batch.
+type VerifyResult struct {
+	Valid bool
+	Err   error
+}
+
+// VerifyBatch verifies solutions concurrently with the default options and
+// one worker per CPU. Results are in the same order as solutions.
+func VerifyBatch(solutions []*Solution) []VerifyResult {
+	return VerifyBatchWithOptions(solutions, VerifyOptions{}, 0)
+}
+
+// VerifyBatchWithOptions verifies solutions with at most workers running at
+// once, or one per CPU when workers is not positive. The plot hash of a public
+// key is only recomputed once per batch, however many solutions use it.
+func VerifyBatchWithOptions(solutions []*Solution, opts VerifyOptions, workers int) []VerifyResult {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(solutions))
+
+	results := make([]VerifyResult, len(solutions))
+	cache := &hashCache{entries: make(map[[32]byte]*hashCacheEntry)}
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if solutions[i] == nil {
+					results[i].Err = errors.New("nil solution")
+					continue
+				}
+				results[i].Valid, results[i].Err = solutions[i].verify(opts, cache)
+			}
+		}()
+	}
+	for i := range solutions {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// hashCache remembers plot hashes computed during a batch. A nil cache just
+// computes every hash.
+type hashCache struct {
+	mu      sync.Mutex
+	entries map[[32]byte]*hashCacheEntry
+}
+
+type hashCacheEntry struct {
+	once sync.Once
+	sum  [32]byte
+	err  error
+}
+
+func (c *hashCache) sum(r *rawSolution) ([32]byte, error) {
+	if c == nil {
+		return r.HashParams.SumBound(r.PublicKey, r.FarmerKey, r.PoolKey)
+	}
+
+	key := hashCacheKey(r)
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &hashCacheEntry{}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+
+	// Workers asking for the same key wait for the first one to finish
+	e.once.Do(func() {
+		e.sum, e.err = r.HashParams.SumBound(r.PublicKey, r.FarmerKey, r.PoolKey)
+	})
+	return e.sum, e.err
+}
+
+// hashCacheKey identifies everything that goes into a plot hash.
+func hashCacheKey(r *rawSolution) [32]byte {
+	var b []byte
+	b = append(b, byte(r.HashParams.Algorithm), r.HashParams.Threads)
+	b = binary.LittleEndian.AppendUint32(b, r.HashParams.Time)
+	b = binary.LittleEndian.AppendUint32(b, r.HashParams.Memory)
+	for _, field := range [][]byte{[]byte(r.HashParams.Salt), r.FarmerKey, r.PoolKey, r.PublicKey} {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(field)))
+		b = append(b, field...)
+	}
+	return sha3.Sum256(b)
+}
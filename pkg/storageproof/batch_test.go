@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestVerifyBatch(t *testing.T) {
+	header := &Header{HashParams: HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt}}
+
+	seed := make([]byte, SchemeEd25519.SeedSize())
+	_, err := rand.Read(seed)
+	if err != nil {
+		t.Fatalf("Failed to create seed: %v", err)
+	}
+	sk, err := SchemeEd25519.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	pkBytes, err := sk.Public().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	hash, err := header.hashKey(pkBytes)
+	if err != nil {
+		t.Fatalf("Failed to hash public key: %v", err)
+	}
+
+	// Many solutions for the same key share one hash computation
+	var solutions []*Solution
+	for i := 0; i < 16; i++ {
+		solution, err := NewSolutionWithSigner(hash[:], i, sk)
+		if err != nil {
+			t.Fatalf("Failed to create solution: %v", err)
+		}
+		solution.bind(header)
+		solutions = append(solutions, solution)
+	}
+
+	forged, err := NewSolutionWithSigner(make([]byte, 32), 0, sk)
+	if err != nil {
+		t.Fatalf("Failed to create solution: %v", err)
+	}
+	forged.bind(header)
+	solutions = append(solutions, forged, nil)
+
+	results := VerifyBatchWithOptions(solutions, VerifyOptions{HashPolicy: PermissiveHashPolicy}, 4)
+	if len(results) != len(solutions) {
+		t.Fatalf("Expected %d results, got %d", len(solutions), len(results))
+	}
+	for i, result := range results[:16] {
+		if result.Err != nil || !result.Valid {
+			t.Errorf("Expected solution %d to be valid, got %+v", i, result)
+		}
+	}
+	if results[16].Err != nil || results[16].Valid {
+		t.Errorf("Expected forged solution to be invalid, got %+v", results[16])
+	}
+	if results[17].Err == nil {
+		t.Errorf("Expected an error for a nil solution")
+	}
+}
@@ -0,0 +1,304 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// cacheMagic starts every index cache file.
+	cacheMagic = "SPIX"
+	// cacheVersion changes whenever the cache file layout does, which
+	// invalidates every existing entry.
+	cacheVersion = 2
+	// cacheSuffix names index cache files, so ClearCache removes nothing else.
+	cacheSuffix = ".idx"
+)
+
+// plotIndex is the cached index of a plot: its header and key table, and the
+// size and modification time of the plot they were read from.
+//
+// On disk it is the magic and version, the size and modification time in
+// nanoseconds, the length-prefixed cache ID and header, the header's size on
+// disk, the SHA3-256 digest of the table and the table as stored in the plot.
+type plotIndex struct {
+	id      string
+	size    int64
+	modTime int64
+	header  *Header
+	table   []byte
+}
+
+// cacheID identifies the plot name of store across runs, or returns false
+// for stores whose plots aren't cached.
+func cacheID(store PlotStore, name string) (string, bool) {
+	switch s := store.(type) {
+	case OSStore:
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return "", false
+		}
+		return abs, true
+	case *S3Store:
+		return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + s3Key(name), true
+	}
+	return "", false
+}
+
+// cacheRoot returns the prefix of the cache IDs of every plot store lists
+// under dir, or false for stores whose plots aren't cached.
+func cacheRoot(store PlotStore, dir string) (string, bool) {
+	switch s := store.(type) {
+	case OSStore:
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", false
+		}
+		return abs, true
+	case *S3Store:
+		prefix := s3Key(dir)
+		if prefix == "." {
+			prefix = ""
+		}
+		return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + prefix, true
+	}
+	return "", false
+}
+
+// cachePath returns the index cache file of a plot ID in dir.
+func cachePath(dir, id string) string {
+	sum := sha3.Sum256([]byte(id))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16])+cacheSuffix)
+}
+
+// loadCachedPlot loads the plot name of store through the index cache in
+// dir. An index is used only if the plot's size and modification time are
+// unchanged and its table digest matches; otherwise the plot is read and the
+// index replaced. Failing to write the cache is not an error.
+func loadCachedPlot(store PlotStore, name, dir string, rebuild bool) (*PlotInfo, error) {
+	id, ok := cacheID(store, name)
+	if !ok {
+		return loadPlot(store, name)
+	}
+	info, err := store.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	path := cachePath(dir, id)
+
+	if !rebuild {
+		if idx, err := readPlotIndex(path); err == nil && idx.id == id &&
+			idx.size == info.Size() && idx.modTime == info.ModTime().UnixNano() {
+			if plot, err := idx.plot(store, name); err == nil {
+				return plot, nil
+			}
+		}
+	}
+
+	plot, table, err := readPlot(store, name)
+	if err != nil {
+		return nil, err
+	}
+	idx := &plotIndex{id: id, size: info.Size(), modTime: info.ModTime().UnixNano(), header: plot.Header, table: table}
+	_ = idx.write(path)
+	return plot, nil
+}
+
+// plot decodes the plot the index describes.
+func (idx *plotIndex) plot(store PlotStore, name string) (*PlotInfo, error) {
+	keyEntries, err := decodeKeyTable(idx.header, idx.table)
+	if err != nil {
+		return nil, err
+	}
+	if idx.header.ID == (PlotID{}) {
+		// Version 1 headers are cached without the ID derived for them
+		idx.header.ID = plotRoot(keyEntries)
+	}
+	return &PlotInfo{Header: idx.header, KeyEntries: keyEntries, Path: name, store: store}, nil
+}
+
+func (idx *plotIndex) write(path string) error {
+	h := *idx.header
+	if h.Version < 2 {
+		// Version 1 headers can't hold an ID, which plot derives again
+		h.ID = PlotID{}
+	}
+	header, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	b := make([]byte, 0, 48+len(idx.id)+len(header)+len(idx.table))
+	b = append(b, cacheMagic...)
+	b = binary.LittleEndian.AppendUint32(b, cacheVersion)
+	b = binary.LittleEndian.AppendUint64(b, uint64(idx.size))
+	b = binary.LittleEndian.AppendUint64(b, uint64(idx.modTime))
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(idx.id)))
+	b = append(b, idx.id...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(header)))
+	b = append(b, header...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(idx.header.Size()))
+	digest := sha3.Sum256(idx.table)
+	b = append(b, digest[:]...)
+	b = append(b, idx.table...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func readPlotIndex(path string) (*plotIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &binaryDecoder{data: data}
+	if string(d.bytes(len(cacheMagic))) != cacheMagic || d.uint32() != cacheVersion {
+		return nil, errors.New("not an index cache file")
+	}
+	idx := &plotIndex{
+		size:    int64(d.uint64()),
+		modTime: int64(d.uint64()),
+	}
+	idx.id = string(d.bytes(int(d.uint16())))
+	headerBytes := d.bytes(int(d.uint32()))
+	headerSize := d.uint32()
+	digest := d.bytes(32)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	idx.header = &Header{}
+	if err := idx.header.UnmarshalBinary(headerBytes); err != nil {
+		return nil, err
+	}
+	if headerSize == 0 {
+		return nil, errors.New("invalid cached header size")
+	}
+	// The plot's header can be larger than the fields this version writes,
+	// or smaller when it predates some
+	idx.header.size = headerSize
+
+	idx.table = d.data
+	if len(idx.table) != int(idx.header.NumKeys)*KeyEntrySize {
+		return nil, errors.New("cached key table has the wrong size")
+	}
+	if sum := sha3.Sum256(idx.table); !bytes.Equal(sum[:], digest) {
+		return nil, errors.New("cached key table digest mismatch")
+	}
+	return idx, nil
+}
+
+// readCacheID reads the cache ID of an index cache file without reading
+// its table.
+func readCacheID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	// The magic, version, size, modification time and ID length, then the ID
+	buf := make([]byte, 26+math.MaxUint16)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", err
+	}
+	d := &binaryDecoder{data: buf[:n]}
+	if string(d.bytes(len(cacheMagic))) != cacheMagic || d.uint32() != cacheVersion {
+		return "", errors.New("not an index cache file")
+	}
+	d.uint64()
+	d.uint64()
+	id := string(d.bytes(int(d.uint16())))
+	return id, d.err
+}
+
+// pruneCache removes the indexes in dir of plots that were under dir of
+// store but aren't among names, the plots it now lists there.
+func pruneCache(store PlotStore, dir string, names []string, cacheDir string) error {
+	root, ok := cacheRoot(store, dir)
+	if !ok {
+		return nil
+	}
+	_, isOS := store.(OSStore)
+	under := func(id string) bool {
+		if !isOS {
+			return strings.HasPrefix(id, root)
+		}
+		return id == root || strings.HasPrefix(id, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+	}
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		if id, ok := cacheID(store, name); ok {
+			listed[id] = true
+		}
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), cacheSuffix) {
+			continue
+		}
+		path := filepath.Join(cacheDir, e.Name())
+		id, err := readCacheID(path)
+		if err != nil || !under(id) || listed[id] {
+			continue
+		}
+		errs = append(errs, os.Remove(path))
+	}
+	return errors.Join(errs...)
+}
+
+// ClearCache removes every plot index from the index cache in dir.
+func ClearCache(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), cacheSuffix) {
+			errs = append(errs, os.Remove(filepath.Join(dir, e.Name())))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/sha3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIndexCache(t *testing.T) {
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	path, err := PlotWithOptions(dir, 1, PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	})
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	opts := LoadOptions{CacheDir: cacheDir}
+	load := func() *PlotInfo {
+		t.Helper()
+		pc, err := LoadPlotsWithOptions([]string{dir}, opts)
+		if err != nil || len(pc.Plots) != 1 {
+			t.Fatalf("Failed to load plot: %v", err)
+		}
+		for _, plot := range pc.Plots {
+			return plot
+		}
+		return nil
+	}
+
+	want := load()
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 1 {
+		t.Fatalf("Expected one index in the cache, got %d", len(entries))
+	}
+	if got := load(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cached plot differs from the plot")
+	}
+
+	// An unchanged size and modification time means the plot isn't read
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[want.Size()+8] ^= 0xff // first hash of the table
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); got.KeyEntries[0] != want.KeyEntries[0] {
+		t.Errorf("Expected the cached table of an unchanged plot")
+	}
+
+	// A rebuild, or a new modification time, rereads it
+	opts.RebuildCache = true
+	if got := load(); got.KeyEntries[0] == want.KeyEntries[0] {
+		t.Errorf("Expected a rebuild to reread the plot")
+	}
+	opts.RebuildCache = false
+	data[want.Size()+8] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, time.Now(), info.ModTime().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected a changed plot to be reread")
+	}
+
+	// A corrupt index is ignored
+	entries, _ := os.ReadDir(cacheDir)
+	index := filepath.Join(cacheDir, entries[0].Name())
+	indexData, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexData[len(indexData)-1] ^= 0xff
+	if err := os.WriteFile(index, indexData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected a corrupt index to be ignored")
+	}
+
+	if err := ClearCache(cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("Expected an empty cache, got %d files", len(entries))
+	}
+}
+
+func TestIndexCacheLegacyPlot(t *testing.T) {
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	h := &Header{Version: 1, NumKeys: 3}
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range h.NumKeys {
+		ke := KeyEntry{Offset: uint64(i), Hash: sha3.Sum256([]byte{byte(i)})}
+		b, _ := ke.MarshalBinary()
+		data = append(data, b...)
+	}
+	data = append(data, make([]byte, int(h.NumKeys)*h.keyDataSize())...)
+	path := filepath.Join(dir, "sp1.plot")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func() *PlotInfo {
+		t.Helper()
+		pc, err := LoadPlotsWithOptions([]string{dir}, LoadOptions{CacheDir: cacheDir})
+		if err != nil || len(pc.Plots) != 1 {
+			t.Fatalf("Failed to load plot: %v", err)
+		}
+		for _, plot := range pc.Plots {
+			return plot
+		}
+		return nil
+	}
+	want := load()
+	if want.ID != plotRoot(want.KeyEntries) {
+		t.Errorf("Expected the plot ID to be derived from its table")
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 1 {
+		t.Fatalf("Expected one index in the cache, got %d", len(entries))
+	}
+
+	// Loading from the index, not the changed plot, derives the same ID
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[want.Size()+8] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cached plot differs from the plot")
+	}
+}
+
+func TestIndexCachePrune(t *testing.T) {
+	base, cacheDir := t.TempDir(), t.TempDir()
+	// b's path extends a's, which must not make its plots a's
+	a, b := filepath.Join(base, "a"), filepath.Join(base, "ab")
+	var paths []string
+	for _, dir := range []string{a, a, b} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path, err := PlotWithOptions(dir, 1, PlotOptions{
+			Format:     FormatSeed,
+			Scheme:     SchemeEd25519,
+			HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+		})
+		if err != nil {
+			t.Fatalf("Failed to plot: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	indexes := func() int {
+		entries, _ := os.ReadDir(cacheDir)
+		return len(entries)
+	}
+	if _, err := LoadPlotsWithOptions([]string{a, b}, LoadOptions{CacheDir: cacheDir}); err != nil || indexes() != 3 {
+		t.Fatalf("Expected 3 indexes, got %d: %v", indexes(), err)
+	}
+
+	// Only a rebuild of the paths that held them drops removed plots
+	if err := os.Remove(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(paths[2]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPlotsWithOptions([]string{a}, LoadOptions{CacheDir: cacheDir}); err != nil || indexes() != 3 {
+		t.Errorf("Expected a load without rebuild to keep every index, got %d: %v", indexes(), err)
+	}
+	if _, err := LoadPlotsWithOptions([]string{a}, LoadOptions{CacheDir: cacheDir, RebuildCache: true}); err != nil || indexes() != 2 {
+		t.Errorf("Expected a rebuild of %s to leave 2 indexes, got %d: %v", a, indexes(), err)
+	}
+	if _, err := LoadPlotsWithOptions([]string{b}, LoadOptions{CacheDir: cacheDir, RebuildCache: true}); err != nil || indexes() != 1 {
+		t.Errorf("Expected a rebuild of %s to leave 1 index, got %d: %v", b, indexes(), err)
+	}
+	if id, err := readCacheID(cachePath(cacheDir, paths[1])); err != nil || id != paths[1] {
+		t.Errorf("Expected the index of %s to remain, got %q: %v", paths[1], id, err)
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"slices"
+)
+
+// DuplicatePlot is a plot file LoadPlots skipped because it repeats a plot,
+// or with LoadOptions.SkipDuplicateKeys keys, already loaded.
+type DuplicatePlot struct {
+	Path string `json:"path"`
+	ID   PlotID `json:"id"`
+	// Of is the path of the loaded plot it repeats.
+	Of string `json:"of"`
+	// SharedKeys is how many of its keys are in loaded plots, all of them
+	// for a copy.
+	SharedKeys int `json:"shared_keys"`
+}
+
+// DuplicateKey is a key hash found in more than one plot.
+type DuplicateKey struct {
+	Hash  [32]byte
+	Plots []PlotID // in ID order
+}
+
+// DuplicateKeys returns the key hashes in more than one of the plots, in
+// hash order. It sorts every key of the collection, so takes memory in
+// proportion to them.
+func (pc *PlotCollection) DuplicateKeys() []DuplicateKey {
+	type key struct {
+		hash [32]byte
+		plot PlotID
+	}
+	keys := make([]key, 0, pc.TotalKeys())
+	for id, plot := range pc.Plots {
+		for _, ke := range plot.KeyEntries {
+			keys = append(keys, key{ke.Hash, id})
+		}
+	}
+	slices.SortFunc(keys, func(a, b key) int {
+		if c := bytes.Compare(a.hash[:], b.hash[:]); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.plot[:], b.plot[:])
+	})
+
+	var dups []DuplicateKey
+	for i := 0; i < len(keys); {
+		j := i + 1
+		plots := []PlotID{keys[i].plot}
+		for ; j < len(keys) && keys[j].hash == keys[i].hash; j++ {
+			if keys[j].plot != plots[len(plots)-1] {
+				plots = append(plots, keys[j].plot)
+			}
+		}
+		if len(plots) > 1 {
+			dups = append(dups, DuplicateKey{Hash: keys[i].hash, Plots: plots})
+		}
+		i = j
+	}
+	return dups
+}
+
+// keyIndex maps the key hashes of the loaded plots to a plot holding them,
+// for LoadOptions.SkipDuplicateKeys.
+type keyIndex map[[32]byte]*PlotInfo
+
+// shared returns the number of keys of plot already indexed and the first
+// plot holding one of them.
+func (idx keyIndex) shared(plot *PlotInfo) (int, *PlotInfo) {
+	var n int
+	var of *PlotInfo
+	for _, ke := range plot.KeyEntries {
+		if other, ok := idx[ke.Hash]; ok {
+			if of == nil {
+				of = other
+			}
+			n++
+		}
+	}
+	return n, of
+}
+
+func (idx keyIndex) add(plot *PlotInfo) {
+	for _, ke := range plot.KeyEntries {
+		idx[ke.Hash] = plot
+	}
+}
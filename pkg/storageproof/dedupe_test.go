@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	plot := func(sub string, kValue uint32) string {
+		t.Helper()
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		// The same key seeds in every plot
+		path, err := PlotWithOptions(filepath.Join(dir, sub), kValue, PlotOptions{
+			Format:     FormatSeed,
+			Scheme:     SchemeEd25519,
+			HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+			Rand:       goldenRand("storageproof duplicates"),
+		})
+		if err != nil {
+			t.Fatalf("Failed to plot: %v", err)
+		}
+		return path
+	}
+	a := plot("a", 1)
+	data, err := os.ReadFile(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := filepath.Join(dir, "b", "sp2-copy.plot")
+	if err := os.Mkdir(filepath.Dir(b), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := plot("c", 2) // its first 1000 keys are those of a
+
+	pc, err := LoadPlots([]string{dir}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pc.Plots) != 2 || len(pc.Duplicates) != 1 {
+		t.Fatalf("Expected 2 plots and 1 duplicate, got %d and %v", len(pc.Plots), pc.Duplicates)
+	}
+	if d := pc.Duplicates[0]; d.Path != b || d.Of != a || d.SharedKeys != 1000 {
+		t.Errorf("Unexpected duplicate %+v", d)
+	}
+	if dups := pc.DuplicateKeys(); len(dups) != 1000 || len(dups[0].Plots) != 2 {
+		t.Errorf("Expected 1000 keys in 2 plots, got %d", len(dups))
+	}
+
+	pc, err = LoadPlotsWithOptions([]string{dir}, LoadOptions{SkipDuplicateKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pc.Plots) != 1 || len(pc.Duplicates) != 2 {
+		t.Fatalf("Expected 1 plot and 2 duplicates, got %d and %v", len(pc.Plots), pc.Duplicates)
+	}
+	if d := pc.Duplicates[1]; d.Path != c || d.Of != a || d.SharedKeys != 1000 {
+		t.Errorf("Unexpected duplicate %+v", d)
+	}
+	if dups := pc.DuplicateKeys(); len(dups) != 0 {
+		t.Errorf("Expected no duplicate keys once skipped, got %d", len(dups))
+	}
+
+	// Merging collections finds copies across them
+	other, err := LoadPlots([]string{filepath.Dir(b)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc.Merge(other)
+	if len(pc.Plots) != 1 || len(pc.Duplicates) != 3 || pc.Duplicates[2].Path != b {
+		t.Errorf("Expected a merged copy to be a duplicate, got %v", pc.Duplicates)
+	}
+}
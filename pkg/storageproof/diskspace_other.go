@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+//go:build !linux && !darwin && !windows
+
+package storageproof
+
+import "errors"
+
+// freeSpace is not supported on this platform.
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// deviceOf is not supported on this platform.
+func deviceOf(path string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+//go:build linux || darwin
+
+package storageproof
+
+import (
+	"strconv"
+	"syscall"
+)
+
+// freeSpace returns the bytes available to unprivileged users in dir.
+func freeSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// deviceOf returns the ID of the device holding path.
+func deviceOf(path string) (string, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(st.Dev), 10), true
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+//go:build windows
+
+package storageproof
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeSpace returns the bytes available to the calling user in dir.
+func freeSpace(dir string) (uint64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+// deviceOf returns the volume holding path.
+func deviceOf(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	volume := filepath.VolumeName(abs)
+	return volume, volume != ""
+}
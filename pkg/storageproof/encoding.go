@@ -0,0 +1,472 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// SolutionEncodingVersion is the version of the binary and CBOR solution encodings.
+const SolutionEncodingVersion = 1
+
+// Flags marking the optional sections of a binary solution.
+const (
+	solutionHasHashParams = 1 << iota
+	solutionHasFarmerKey
+	solutionHasPlotID
+	solutionHasProof
+
+	solutionKnownFlags = solutionHasHashParams | solutionHasFarmerKey | solutionHasPlotID | solutionHasProof
+)
+
+// rawSolution is a Solution with its ascii85 fields decoded.
+type rawSolution struct {
+	Hash       []byte
+	Distance   int
+	Scheme     SchemeID
+	HashParams *HashParams
+	FarmerKey  []byte
+	PoolKey    []byte
+	PlotID     []byte
+	Proof      *rawInclusionProof
+	PublicKey  []byte
+	Signature  []byte
+}
+
+type rawInclusionProof struct {
+	Index   uint32
+	NumKeys uint32
+	Path    [][32]byte
+}
+
+// raw decodes the ascii85 fields of the solution.
+func (s *Solution) raw() (*rawSolution, error) {
+	if !s.Scheme.Valid() {
+		return nil, errors.New("unknown signature scheme")
+	}
+
+	r := &rawSolution{Distance: s.Distance, Scheme: s.Scheme, HashParams: s.HashParams}
+	var err error
+	if r.Hash, err = decodeAscii85(s.Hash, 32); err != nil {
+		return nil, err
+	}
+	if r.FarmerKey, err = decodeAscii85(s.FarmerKey, 0xffff); err != nil {
+		return nil, err
+	}
+	if r.PoolKey, err = decodeAscii85(s.PoolKey, 0xffff); err != nil {
+		return nil, err
+	}
+	if r.PlotID, err = decodeAscii85(s.PlotID, PlotIDSize); err != nil {
+		return nil, err
+	}
+	if s.Proof != nil {
+		if len(r.Hash) != 32 || len(r.PlotID) != PlotIDSize {
+			return nil, errors.New("inclusion proof requires a full hash and plot ID")
+		}
+		path, err := decodeAscii85(s.Proof.Path, maxProofLen*32)
+		if err != nil {
+			return nil, err
+		}
+		if len(path)%32 != 0 {
+			return nil, errors.New("inclusion path must be a sequence of 32-byte hashes")
+		}
+		r.Proof = &rawInclusionProof{Index: s.Proof.Index, NumKeys: s.Proof.NumKeys}
+		for i := 0; i < len(path); i += 32 {
+			r.Proof.Path = append(r.Proof.Path, [32]byte(path[i:i+32]))
+		}
+	}
+	if r.PublicKey, err = decodeAscii85(s.PublicKey, s.Scheme.PublicKeySize()); err != nil {
+		return nil, err
+	}
+	if r.Signature, err = decodeAscii85(s.Signature, s.Scheme.SignatureSize()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// validate checks the invariants shared by the binary and CBOR encodings,
+// which only accept one representation of each solution.
+func (r *rawSolution) validate() error {
+	if len(r.Hash) != 32 {
+		return errors.New("solution hash must be 32 bytes")
+	}
+	if r.Distance < 0 || r.Distance > hashBits {
+		return fmt.Errorf("solution distance %d out of range", r.Distance)
+	}
+	if !r.Scheme.Valid() {
+		return errors.New("unknown signature scheme")
+	}
+	if len(r.PublicKey) != r.Scheme.PublicKeySize() {
+		return fmt.Errorf("%s public key must be %d bytes", r.Scheme, r.Scheme.PublicKeySize())
+	}
+	if len(r.Signature) != r.Scheme.SignatureSize() {
+		return fmt.Errorf("%s signature must be %d bytes", r.Scheme, r.Scheme.SignatureSize())
+	}
+	if r.HashParams != nil {
+		if err := r.HashParams.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.FarmerKey) > 0xffff || len(r.PoolKey) > 0xffff {
+		return errors.New("farmer or pool key too long")
+	}
+	if len(r.FarmerKey) == 0 && len(r.PoolKey) != 0 {
+		return errors.New("pool key requires a farmer key")
+	}
+	if len(r.PlotID) != 0 && len(r.PlotID) != PlotIDSize {
+		return fmt.Errorf("plot ID must be %d bytes", PlotIDSize)
+	}
+	if r.Proof != nil {
+		if len(r.PlotID) == 0 {
+			return errors.New("inclusion proof requires a plot ID")
+		}
+		if len(r.Proof.Path) > maxProofLen {
+			return errors.New("inclusion path too long")
+		}
+	}
+	return nil
+}
+
+func (r *rawSolution) solution() *Solution {
+	return &Solution{
+		Hash:       encodeAscii85(r.Hash),
+		Distance:   r.Distance,
+		Scheme:     r.Scheme,
+		HashParams: r.HashParams,
+		FarmerKey:  encodeAscii85(r.FarmerKey),
+		PoolKey:    encodeAscii85(r.PoolKey),
+		PlotID:     encodeAscii85(r.PlotID),
+		Proof:      r.Proof.proof(),
+		PublicKey:  encodeAscii85(r.PublicKey),
+		Signature:  encodeAscii85(r.Signature),
+	}
+}
+
+func (p *rawInclusionProof) proof() *InclusionProof {
+	if p == nil {
+		return nil
+	}
+	return &InclusionProof{Index: p.Index, NumKeys: p.NumKeys, Path: encodeAscii85(p.joinPath())}
+}
+
+// joinPath returns the hashes of the path as one byte slice.
+func (p *rawInclusionProof) joinPath() []byte {
+	b := make([]byte, 0, len(p.Path)*32)
+	for _, hash := range p.Path {
+		b = append(b, hash[:]...)
+	}
+	return b
+}
+
+// MarshalBinary encodes the solution in its canonical binary form:
+//
+//	version   uint8
+//	scheme    uint8
+//	flags     uint8
+//	distance  uint16
+//	hash      [32]byte
+//	if flags&1: algorithm uint8, threads uint8, time uint32, memory uint32,
+//	            salt length uint16, salt
+//	if flags&2: farmer key length uint16, farmer key,
+//	            pool key length uint16, pool key
+//	if flags&4: plot ID [32]byte
+//	if flags&8: key index uint32, number of keys uint32,
+//	            path length uint8, path [length][32]byte
+//	public key length uint32, public key
+//	signature length uint32, signature
+//
+// All integers are little endian.
+func (s *Solution) MarshalBinary() ([]byte, error) {
+	r, err := s.raw()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	var flags byte
+	if r.HashParams != nil {
+		flags |= solutionHasHashParams
+	}
+	if len(r.FarmerKey) != 0 {
+		flags |= solutionHasFarmerKey
+	}
+	if len(r.PlotID) != 0 {
+		flags |= solutionHasPlotID
+	}
+	if r.Proof != nil {
+		flags |= solutionHasProof
+	}
+
+	b := []byte{SolutionEncodingVersion, byte(r.Scheme), flags}
+	b = binary.LittleEndian.AppendUint16(b, uint16(r.Distance))
+	b = append(b, r.Hash...)
+	if r.HashParams != nil {
+		b = append(b, byte(r.HashParams.Algorithm), r.HashParams.Threads)
+		b = binary.LittleEndian.AppendUint32(b, r.HashParams.Time)
+		b = binary.LittleEndian.AppendUint32(b, r.HashParams.Memory)
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(r.HashParams.Salt)))
+		b = append(b, r.HashParams.Salt...)
+	}
+	if len(r.FarmerKey) != 0 {
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(r.FarmerKey)))
+		b = append(b, r.FarmerKey...)
+		b = binary.LittleEndian.AppendUint16(b, uint16(len(r.PoolKey)))
+		b = append(b, r.PoolKey...)
+	}
+	b = append(b, r.PlotID...)
+	if r.Proof != nil {
+		b = binary.LittleEndian.AppendUint32(b, r.Proof.Index)
+		b = binary.LittleEndian.AppendUint32(b, r.Proof.NumKeys)
+		b = append(b, byte(len(r.Proof.Path)))
+		b = append(b, r.Proof.joinPath()...)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(r.PublicKey)))
+	b = append(b, r.PublicKey...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(r.Signature)))
+	b = append(b, r.Signature...)
+	return b, nil
+}
+
+func (s *Solution) UnmarshalBinary(data []byte) error {
+	d := &binaryDecoder{data: data}
+	if version := d.uint8(); d.err == nil && version != SolutionEncodingVersion {
+		return fmt.Errorf("unsupported solution encoding version %d", version)
+	}
+
+	r := &rawSolution{Scheme: SchemeID(d.uint8())}
+	flags := d.uint8()
+	if flags&^solutionKnownFlags != 0 {
+		return errors.New("unknown solution flags")
+	}
+	r.Distance = int(d.uint16())
+	r.Hash = d.bytes(32)
+	if flags&solutionHasHashParams != 0 {
+		r.HashParams = &HashParams{
+			Algorithm: HashAlgorithm(d.uint8()),
+			Threads:   d.uint8(),
+			Time:      d.uint32(),
+			Memory:    d.uint32(),
+		}
+		r.HashParams.Salt = string(d.bytes(int(d.uint16())))
+	}
+	if flags&solutionHasFarmerKey != 0 {
+		r.FarmerKey = d.bytes(int(d.uint16()))
+		r.PoolKey = d.bytes(int(d.uint16()))
+		if d.err == nil && len(r.FarmerKey) == 0 {
+			return errors.New("solution farmer key flag set without a farmer key")
+		}
+	}
+	if flags&solutionHasPlotID != 0 {
+		r.PlotID = d.bytes(PlotIDSize)
+	}
+	if flags&solutionHasProof != 0 {
+		r.Proof = &rawInclusionProof{Index: d.uint32(), NumKeys: d.uint32()}
+		n := int(d.uint8())
+		if n > maxProofLen {
+			return errors.New("inclusion path too long")
+		}
+		for range n {
+			if hash := d.bytes(32); hash != nil {
+				r.Proof.Path = append(r.Proof.Path, [32]byte(hash))
+			}
+		}
+	}
+	r.PublicKey = d.bytes(int(d.uint32()))
+	r.Signature = d.bytes(int(d.uint32()))
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) != 0 {
+		return errors.New("trailing data after solution")
+	}
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	*s = *r.solution()
+	return nil
+}
+
+// Digest returns the SHA3-256 hash of the canonical binary encoding, which
+// identifies a solution regardless of how its JSON happens to be formatted.
+func (s *Solution) Digest() ([32]byte, error) {
+	b, err := s.MarshalBinary()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha3.Sum256(b), nil
+}
+
+// binaryDecoder reads little endian fields, remembering the first error.
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.data) {
+		d.err = errors.New("solution data too short")
+		return nil
+	}
+	b := d.data[:n:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *binaryDecoder) uint8() uint8 {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *binaryDecoder) uint16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *binaryDecoder) uint32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *binaryDecoder) uint64() uint64 {
+	if b := d.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// solutionCBOR is the CBOR form of a solution, using integer map keys and
+// raw byte strings.
+type solutionCBOR struct {
+	Version    uint8           `cbor:"0,keyasint"`
+	Hash       []byte          `cbor:"1,keyasint"`
+	Distance   int             `cbor:"2,keyasint"`
+	Scheme     uint8           `cbor:"3,keyasint"`
+	HashParams *hashParamsCBOR `cbor:"4,keyasint,omitempty"`
+	FarmerKey  []byte          `cbor:"5,keyasint,omitempty"`
+	PoolKey    []byte          `cbor:"6,keyasint,omitempty"`
+	PublicKey  []byte          `cbor:"7,keyasint"`
+	Signature  []byte          `cbor:"8,keyasint"`
+	PlotID     []byte          `cbor:"9,keyasint,omitempty"`
+	Proof      *proofCBOR      `cbor:"10,keyasint,omitempty"`
+}
+
+type proofCBOR struct {
+	Index   uint32 `cbor:"0,keyasint"`
+	NumKeys uint32 `cbor:"1,keyasint"`
+	Path    []byte `cbor:"2,keyasint"`
+}
+
+type hashParamsCBOR struct {
+	Algorithm uint8  `cbor:"0,keyasint"`
+	Time      uint32 `cbor:"1,keyasint,omitempty"`
+	Memory    uint32 `cbor:"2,keyasint,omitempty"`
+	Threads   uint8  `cbor:"3,keyasint,omitempty"`
+	Salt      string `cbor:"4,keyasint"`
+}
+
+var (
+	cborEncMode, _ = cbor.CoreDetEncOptions().EncMode()
+	cborDecMode, _ = cbor.DecOptions{
+		DupMapKey:         cbor.DupMapKeyEnforcedAPF,
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	}.DecMode()
+)
+
+// MarshalCBOR encodes the solution as deterministic CBOR.
+func (s *Solution) MarshalCBOR() ([]byte, error) {
+	r, err := s.raw()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	c := solutionCBOR{
+		Version:   SolutionEncodingVersion,
+		Hash:      r.Hash,
+		Distance:  r.Distance,
+		Scheme:    uint8(r.Scheme),
+		FarmerKey: r.FarmerKey,
+		PoolKey:   r.PoolKey,
+		PlotID:    r.PlotID,
+		PublicKey: r.PublicKey,
+		Signature: r.Signature,
+	}
+	if r.Proof != nil {
+		c.Proof = &proofCBOR{Index: r.Proof.Index, NumKeys: r.Proof.NumKeys, Path: r.Proof.joinPath()}
+	}
+	if r.HashParams != nil {
+		c.HashParams = &hashParamsCBOR{
+			Algorithm: uint8(r.HashParams.Algorithm),
+			Time:      r.HashParams.Time,
+			Memory:    r.HashParams.Memory,
+			Threads:   r.HashParams.Threads,
+			Salt:      r.HashParams.Salt,
+		}
+	}
+	return cborEncMode.Marshal(c)
+}
+
+func (s *Solution) UnmarshalCBOR(data []byte) error {
+	var c solutionCBOR
+	if err := cborDecMode.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	if c.Version != SolutionEncodingVersion {
+		return fmt.Errorf("unsupported solution encoding version %d", c.Version)
+	}
+
+	r := &rawSolution{
+		Hash:      c.Hash,
+		Distance:  c.Distance,
+		Scheme:    SchemeID(c.Scheme),
+		FarmerKey: c.FarmerKey,
+		PoolKey:   c.PoolKey,
+		PlotID:    c.PlotID,
+		PublicKey: c.PublicKey,
+		Signature: c.Signature,
+	}
+	if c.Proof != nil {
+		if len(c.Proof.Path)%32 != 0 {
+			return errors.New("inclusion path must be a sequence of 32-byte hashes")
+		}
+		r.Proof = &rawInclusionProof{Index: c.Proof.Index, NumKeys: c.Proof.NumKeys}
+		for i := 0; i < len(c.Proof.Path); i += 32 {
+			r.Proof.Path = append(r.Proof.Path, [32]byte(c.Proof.Path[i:i+32]))
+		}
+	}
+	if c.HashParams != nil {
+		r.HashParams = &HashParams{
+			Algorithm: HashAlgorithm(c.HashParams.Algorithm),
+			Time:      c.HashParams.Time,
+			Memory:    c.HashParams.Memory,
+			Threads:   c.HashParams.Threads,
+			Salt:      c.HashParams.Salt,
+		}
+	}
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	*s = *r.solution()
+	return nil
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+func TestSolutionEncodings(t *testing.T) {
+	seed := make([]byte, SchemeMLDSA87.SeedSize())
+	_, err := rand.Read(seed)
+	if err != nil {
+		t.Fatalf("Failed to create seed: %v", err)
+	}
+	sk, err := SchemeMLDSA87.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	challengeHash := make([]byte, 32)
+	_, err = rand.Read(challengeHash)
+	if err != nil {
+		t.Fatalf("Failed to create challenge hash: %v", err)
+	}
+	solution, err := NewSolutionWithSigner(challengeHash, 42, sk)
+	if err != nil {
+		t.Fatalf("Failed to create solution: %v", err)
+	}
+	solution.bind(&Header{
+		HashParams: DefaultHashParams(),
+		FarmerKey:  []byte("farmer"),
+		PoolKey:    []byte("pool"),
+	})
+
+	jsonBytes, err := json.Marshal(solution)
+	if err != nil {
+		t.Fatalf("Failed to marshal JSON: %v", err)
+	}
+	binBytes, err := solution.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal binary: %v", err)
+	}
+	if len(binBytes) >= len(jsonBytes) {
+		t.Errorf("Expected binary encoding (%d bytes) to be smaller than JSON (%d bytes)", len(binBytes), len(jsonBytes))
+	}
+
+	var fromBinary Solution
+	err = fromBinary.UnmarshalBinary(binBytes)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal binary: %v", err)
+	}
+	again, err := fromBinary.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to re-marshal binary: %v", err)
+	}
+	if !bytes.Equal(binBytes, again) {
+		t.Errorf("Binary encoding did not round trip")
+	}
+	if fromBinary.FarmerKey != solution.FarmerKey || *fromBinary.HashParams != *solution.HashParams {
+		t.Errorf("Binary encoding lost the farmer binding")
+	}
+
+	err = fromBinary.UnmarshalBinary(append(binBytes, 0))
+	if err == nil {
+		t.Errorf("Expected trailing data to be rejected")
+	}
+
+	cborBytes, err := solution.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("Failed to marshal CBOR: %v", err)
+	}
+	var fromCBOR Solution
+	err = fromCBOR.UnmarshalCBOR(cborBytes)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal CBOR: %v", err)
+	}
+
+	want, err := solution.Digest()
+	if err != nil {
+		t.Fatalf("Failed to digest solution: %v", err)
+	}
+	for name, s := range map[string]*Solution{"binary": &fromBinary, "CBOR": &fromCBOR} {
+		got, err := s.Digest()
+		if err != nil {
+			t.Fatalf("Failed to digest %s solution: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("Expected %s solution to have the same digest", name)
+		}
+	}
+}
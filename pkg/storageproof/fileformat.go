@@ -3,20 +3,98 @@
 
 package storageproof
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
 
-const Version = 1
+const Version = 2
+
+const (
+	// legacyHeaderSize is the size of a version 1 header, which carries no
+	// size or format fields.
+	legacyHeaderSize = 40
+	// minHeaderSize is the size of the smallest version 2 header. Fields
+	// added since then are only present when the header size covers them.
+	minHeaderSize = 48
+	// hashParamsOffset is where the hash parameters start in a version 2
+	// header, directly after the signature scheme.
+	hashParamsOffset = 52
+	// saltOffset is where the variable-length hash salt starts. Headers that
+	// end before it use DefaultHashParams.
+	saltOffset = 64
+	// KeyEntrySize is the size of a single encoded KeyEntry.
+	KeyEntrySize = 40
+	// maxHeaderSize bounds the size of a header ReadHeader accepts.
+	maxHeaderSize = 1 << 20
+)
+
+// KeyFormat describes what is stored in the key data section of a plot.
+type KeyFormat uint32
+
+const (
+	// FormatPrivateKey stores the full packed private key for every entry.
+	// Version 1 plots always use this format.
+	FormatPrivateKey KeyFormat = 0
+	// FormatSeed stores only the 32-byte seed each key was generated from.
+	// LookUp expands the winning seed into a private key on demand.
+	FormatSeed KeyFormat = 1
+)
+
+func (f KeyFormat) String() string {
+	switch f {
+	case FormatPrivateKey:
+		return "private-key"
+	case FormatSeed:
+		return "seed"
+	}
+	return fmt.Sprintf("format(%d)", uint32(f))
+}
+
+func (f KeyFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f *KeyFormat) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "private-key":
+		*f = FormatPrivateKey
+	case "seed":
+		*f = FormatSeed
+	default:
+		return fmt.Errorf("unknown key format %q", text)
+	}
+	return nil
+}
 
 // Header defines the structure of the plot file header.
 // The header will be followed by the key data.
-// The key data will be a sequence of private keys.
-// The offsets in the header will point to the start of each private key.
+// The key data will be a sequence of private keys or key seeds, depending on Format.
+// The offsets in the header will point to the start of each private key or seed.
 // The hashes in the header will be the Argon2 hash of the corresponding public key.
+//
+// Version 2 headers extend the version 1 layout with the total header size,
+// so readers can skip fields they do not understand, the key format, the
+// signature scheme, the parameters of the public key hash, the farmer and
+// pool keys the plot is bound to, the plot's metadata and its ID.
 
 type Header struct {
-	Version       uint32
-	NumKeys       uint32
-	LibVersion    [32]byte // Fixed-size array for a 32-character string
+	Version    uint32
+	NumKeys    uint32
+	LibVersion [32]byte // Fixed-size array for a 32-character string
+	Format     KeyFormat
+	Scheme     SchemeID
+	HashParams HashParams
+	FarmerKey  []byte // empty for plots not bound to a farmer
+	PoolKey    []byte
+	Metadata   Metadata
+	// ID is the root of the Merkle tree over the key table. It is zero in
+	// plots written before IDs, which LoadPlots fills in from the table.
+	ID PlotID
+
+	size uint32 // encoded size as read from disk, zero when not yet known
 }
 
 // KeyEntry defines the structure of the key lookup table in the header.
@@ -26,21 +104,231 @@ type KeyEntry struct {
 	Hash   [32]byte // Assuming a 32-byte hash output
 }
 
+// Size returns the number of bytes the header occupies on disk.
+func (h *Header) Size() int {
+	if h.size != 0 {
+		return int(h.size)
+	}
+	return h.marshalledSize()
+}
+
+// marshalledSize is the size of the header MarshalBinary writes, which can
+// be smaller than a header read from a newer writer.
+func (h *Header) marshalledSize() int {
+	if h.Version < 2 {
+		return legacyHeaderSize
+	}
+	meta, _ := h.Metadata.marshal()
+	return saltOffset + len(h.HashParams.Salt) + 4 + len(h.FarmerKey) + len(h.PoolKey) + 4 + len(meta) + PlotIDSize
+}
+
+// hashKey hashes a packed public key the way this plot's table was built.
+func (h *Header) hashKey(pkBytes []byte) ([32]byte, error) {
+	return h.HashParams.SumBound(pkBytes, h.FarmerKey, h.PoolKey)
+}
+
+// keyDataSize returns the size of each key data record in the plot.
+func (h *Header) keyDataSize() int {
+	if h.Format == FormatSeed {
+		return h.Scheme.SeedSize()
+	}
+	return h.Scheme.PrivateKeySize()
+}
+
 func (h *Header) MarshalBinary() ([]byte, error) {
-	b := make([]byte, 40)
+	if h.Version < 2 {
+		if h.Format != FormatPrivateKey || h.Scheme != SchemeMLDSA87 {
+			return nil, errors.New("version 1 headers only support ML-DSA-87 private keys")
+		}
+		if h.HashParams != (HashParams{}) && h.HashParams != DefaultHashParams() {
+			return nil, errors.New("version 1 headers only support the default hash parameters")
+		}
+		if len(h.FarmerKey) != 0 || len(h.PoolKey) != 0 {
+			return nil, errors.New("version 1 headers can't be bound to a farmer")
+		}
+		if !h.Metadata.isZero() {
+			return nil, errors.New("version 1 headers can't hold metadata")
+		}
+		if h.ID != (PlotID{}) {
+			return nil, errors.New("version 1 headers can't hold a plot ID")
+		}
+		b := make([]byte, legacyHeaderSize)
+		h.marshalLegacy(b)
+		return b, nil
+	}
+
+	if err := h.HashParams.Validate(); err != nil {
+		return nil, err
+	}
+	if len(h.FarmerKey) > 0xffff || len(h.PoolKey) > 0xffff {
+		return nil, errors.New("farmer or pool key too long")
+	}
+	if len(h.FarmerKey) == 0 && len(h.PoolKey) != 0 {
+		return nil, errors.New("pool key requires a farmer key")
+	}
+	meta, err := h.Metadata.marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	size := h.marshalledSize()
+	b := make([]byte, size)
+	h.marshalLegacy(b)
+	binary.LittleEndian.PutUint32(b[40:44], uint32(size))
+	binary.LittleEndian.PutUint32(b[44:48], uint32(h.Format))
+	binary.LittleEndian.PutUint32(b[48:52], uint32(h.Scheme))
+	b[52] = byte(h.HashParams.Algorithm)
+	b[53] = h.HashParams.Threads
+	binary.LittleEndian.PutUint16(b[54:56], uint16(len(h.HashParams.Salt)))
+	binary.LittleEndian.PutUint32(b[56:60], h.HashParams.Time)
+	binary.LittleEndian.PutUint32(b[60:64], h.HashParams.Memory)
+	copy(b[saltOffset:], h.HashParams.Salt)
+
+	keysOffset := saltOffset + len(h.HashParams.Salt)
+	binary.LittleEndian.PutUint16(b[keysOffset:keysOffset+2], uint16(len(h.FarmerKey)))
+	binary.LittleEndian.PutUint16(b[keysOffset+2:keysOffset+4], uint16(len(h.PoolKey)))
+	copy(b[keysOffset+4:], h.FarmerKey)
+	copy(b[keysOffset+4+len(h.FarmerKey):], h.PoolKey)
+
+	metaOffset := keysOffset + 4 + len(h.FarmerKey) + len(h.PoolKey)
+	binary.LittleEndian.PutUint32(b[metaOffset:metaOffset+4], uint32(len(meta)))
+	copy(b[metaOffset+4:], meta)
+	copy(b[metaOffset+4+len(meta):], h.ID[:])
+	return b, nil
+}
+
+func (h *Header) marshalLegacy(b []byte) {
 	binary.LittleEndian.PutUint32(b[0:4], h.Version)
 	binary.LittleEndian.PutUint32(b[4:8], h.NumKeys)
 	copy(b[8:40], h.LibVersion[:])
-	return b, nil
 }
 
 func (h *Header) UnmarshalBinary(data []byte) error {
+	if len(data) < legacyHeaderSize {
+		return errors.New("plot header too short")
+	}
 	h.Version = binary.LittleEndian.Uint32(data[0:4])
 	h.NumKeys = binary.LittleEndian.Uint32(data[4:8])
 	copy(h.LibVersion[:], data[8:40])
+
+	// Defaults for fields an older writer did not record
+	h.Format = FormatPrivateKey
+	h.Scheme = SchemeMLDSA87
+	h.HashParams = DefaultHashParams()
+	h.FarmerKey = nil
+	h.PoolKey = nil
+	h.Metadata = Metadata{}
+	h.ID = PlotID{}
+
+	if h.Version < 2 {
+		h.size = legacyHeaderSize
+		return nil
+	}
+
+	if len(data) < minHeaderSize {
+		return errors.New("plot header too short")
+	}
+	h.size = binary.LittleEndian.Uint32(data[40:44])
+	if h.size < minHeaderSize || int(h.size) > len(data) {
+		return errors.New("invalid plot header size")
+	}
+	data = data[:h.size]
+
+	h.Format = KeyFormat(binary.LittleEndian.Uint32(data[44:48]))
+	if h.Format != FormatPrivateKey && h.Format != FormatSeed {
+		return errors.New("unknown plot key format")
+	}
+
+	if len(data) >= hashParamsOffset {
+		scheme := binary.LittleEndian.Uint32(data[48:52])
+		if scheme > 0xff || !SchemeID(scheme).Valid() {
+			return errors.New("unknown plot signature scheme")
+		}
+		h.Scheme = SchemeID(scheme)
+	}
+
+	if len(data) >= saltOffset {
+		saltLen := int(binary.LittleEndian.Uint16(data[54:56]))
+		if len(data) < saltOffset+saltLen {
+			return errors.New("plot header too short for hash salt")
+		}
+		h.HashParams = HashParams{
+			Algorithm: HashAlgorithm(data[52]),
+			Threads:   data[53],
+			Time:      binary.LittleEndian.Uint32(data[56:60]),
+			Memory:    binary.LittleEndian.Uint32(data[60:64]),
+			Salt:      string(data[saltOffset : saltOffset+saltLen]),
+		}
+		if err := h.HashParams.Validate(); err != nil {
+			return err
+		}
+
+		keysOffset := saltOffset + saltLen
+		if len(data) >= keysOffset+4 {
+			farmerLen := int(binary.LittleEndian.Uint16(data[keysOffset : keysOffset+2]))
+			poolLen := int(binary.LittleEndian.Uint16(data[keysOffset+2 : keysOffset+4]))
+			keys := data[keysOffset+4:]
+			if len(keys) < farmerLen+poolLen {
+				return errors.New("plot header too short for farmer keys")
+			}
+			if farmerLen == 0 && poolLen != 0 {
+				return errors.New("pool key requires a farmer key")
+			}
+			if farmerLen != 0 {
+				h.FarmerKey = append([]byte(nil), keys[:farmerLen]...)
+			}
+			if poolLen != 0 {
+				h.PoolKey = append([]byte(nil), keys[farmerLen:farmerLen+poolLen]...)
+			}
+
+			if meta := keys[farmerLen+poolLen:]; len(meta) >= 4 {
+				metaLen := binary.LittleEndian.Uint32(meta[0:4])
+				if uint64(metaLen) > uint64(len(meta)-4) {
+					return errors.New("plot header too short for metadata")
+				}
+				if err := h.Metadata.unmarshal(meta[4 : 4+metaLen]); err != nil {
+					return err
+				}
+				if id := meta[4+metaLen:]; len(id) >= PlotIDSize {
+					copy(h.ID[:], id)
+				}
+			}
+		}
+	}
 	return nil
 }
 
+// ReadHeader reads a plot header of any supported version from r, leaving r
+// positioned at the start of the key lookup table.
+func ReadHeader(r io.Reader) (*Header, error) {
+	data := make([]byte, legacyHeaderSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	if binary.LittleEndian.Uint32(data[0:4]) >= 2 {
+		sizeBytes := make([]byte, 4)
+		if _, err := io.ReadFull(r, sizeBytes); err != nil {
+			return nil, err
+		}
+		size := binary.LittleEndian.Uint32(sizeBytes)
+		if size < minHeaderSize || size > maxHeaderSize {
+			return nil, errors.New("invalid plot header size")
+		}
+		rest := make([]byte, int(size)-legacyHeaderSize-4)
+		if _, err := io.ReadFull(r, rest); err != nil {
+			return nil, err
+		}
+		data = append(append(data, sizeBytes...), rest...)
+	}
+
+	h := &Header{}
+	if err := h.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
 func (ke *KeyEntry) MarshalBinary() ([]byte, error) {
 	b := make([]byte, 40)
 	binary.LittleEndian.PutUint64(b[0:8], ke.Offset)
@@ -49,7 +337,10 @@ func (ke *KeyEntry) MarshalBinary() ([]byte, error) {
 }
 
 func (ke *KeyEntry) UnmarshalBinary(data []byte) error {
+	if len(data) < KeyEntrySize {
+		return errors.New("key entry too short")
+	}
 	ke.Offset = binary.LittleEndian.Uint64(data[0:8])
 	copy(ke.Hash[:], data[8:40])
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHeaderRoundTrip(t *testing.T) {
+	for _, h := range []*Header{
+		{Version: 1, NumKeys: 1000},
+		{Version: Version, NumKeys: 2000, Format: FormatPrivateKey, HashParams: DefaultHashParams()},
+		{Version: Version, NumKeys: 3000, Format: FormatSeed, Scheme: SchemeEd25519,
+			HashParams: HashParams{Algorithm: HashSHAKE256, Salt: "testnet"},
+			FarmerKey:  []byte("farmer"), PoolKey: []byte("pool")},
+	} {
+		copy(h.LibVersion[:], libVersion)
+
+		b, err := h.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal header: %v", err)
+		}
+		if len(b) != h.Size() {
+			t.Errorf("Expected %d header bytes, got %d", h.Size(), len(b))
+		}
+
+		// Append a key entry to make sure ReadHeader stops at the table
+		r := bytes.NewReader(append(b, make([]byte, KeyEntrySize)...))
+		got, err := ReadHeader(r)
+		if err != nil {
+			t.Fatalf("Failed to read header: %v", err)
+		}
+		if r.Len() != KeyEntrySize {
+			t.Errorf("Expected reader to stop at the key table, %d bytes left", r.Len())
+		}
+		if h.Version < 2 {
+			h.HashParams = DefaultHashParams()
+		}
+		if got.Version != h.Version || got.NumKeys != h.NumKeys || got.Format != h.Format || got.Scheme != h.Scheme || got.LibVersion != h.LibVersion || got.HashParams != h.HashParams ||
+			!bytes.Equal(got.FarmerKey, h.FarmerKey) || !bytes.Equal(got.PoolKey, h.PoolKey) {
+			t.Errorf("Header mismatch: got %+v, want %+v", got, h)
+		}
+	}
+}
+
+func TestHeaderMetadata(t *testing.T) {
+	h := &Header{Version: Version, NumKeys: 1000, HashParams: DefaultHashParams()}
+	withoutMeta, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Metadata = Metadata{
+		CreatedAt:      time.Date(2025, 6, 1, 12, 0, 0, 5, time.UTC),
+		Hostname:       "farm1",
+		PlotterVersion: "plotlib " + libVersion,
+		Labels:         map[string]string{"disk": "sdb", "batch": ""},
+		Unknown:        []MetadataRecord{{Type: 0x7fff, Value: []byte("from the future")}},
+	}
+	b, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal header: %v", err)
+	}
+	got, err := ReadHeader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Failed to read header: %v", err)
+	}
+	if !reflect.DeepEqual(got.Metadata, h.Metadata) {
+		t.Errorf("Metadata mismatch: got %+v, want %+v", got.Metadata, h.Metadata)
+	}
+
+	// Readers that predate metadata skip it by the header size
+	fields := len(withoutMeta) - 4 - PlotIDSize
+	if !bytes.Equal(b[minHeaderSize:fields], withoutMeta[minHeaderSize:fields]) {
+		t.Errorf("Metadata changed the fields before it")
+	}
+
+	// Damaged records are rejected
+	b[len(b)-PlotIDSize-len("from the future")-4] = 0xff
+	if _, err := ReadHeader(bytes.NewReader(b)); err == nil {
+		t.Errorf("Expected a record overrunning the section to be rejected")
+	}
+}
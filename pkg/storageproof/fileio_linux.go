@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+//go:build linux
+
+package storageproof
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// preallocate reserves size bytes for file.
+func preallocate(file *os.File, size uint64) error {
+	return unix.Fallocate(int(file.Fd()), 0, 0, int64(size))
+}
+
+// openDirect opens path for writing with O_DIRECT.
+func openDirect(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|syscall.O_DIRECT, 0)
+}
@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+//go:build !linux
+
+package storageproof
+
+import (
+	"errors"
+	"os"
+)
+
+// preallocate is not supported on this platform.
+func preallocate(file *os.File, size uint64) error {
+	return errors.ErrUnsupported
+}
+
+// openDirect is not supported on this platform.
+func openDirect(path string) (*os.File, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// FillOptions controls how Fill and PlotSeries create plots.
+type FillOptions struct {
+	PlotOptions
+	// KValue is the size of every plot in thousands of keys.
+	KValue uint32
+	// Reserve is the number of bytes to leave free.
+	Reserve uint64
+	// Concurrency is how many plots are created at once, at least one.
+	Concurrency int
+	// Progress, if set, is called as each plot starts and finishes. Calls
+	// may come from several goroutines but never at the same time.
+	Progress func(FillProgress)
+}
+
+// FillProgress reports on one plot of a Fill.
+type FillProgress struct {
+	// Index counts plots from 1 to Total.
+	Index int `json:"index"`
+	Total int `json:"total"`
+	// Done is false when the plot starts and true when it finishes, after
+	// which Path or Err and Duration are set.
+	Done     bool          `json:"done"`
+	Path     string        `json:"path,omitempty"`
+	Err      error         `json:"-"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+// FillCount returns how many plots of kValue thousand keys fit in dir while
+// leaving reserve bytes free.
+func FillCount(dir string, kValue uint32, reserve uint64, opts PlotOptions) (int, error) {
+	h, err := newPlotHeader(kValue, opts)
+	if err != nil {
+		return 0, err
+	}
+	free, err := storeFreeSpace(opts.Store, dir)
+	if err != nil {
+		return 0, err
+	}
+	if free <= reserve {
+		return 0, nil
+	}
+	return int((free - reserve) / plotFileSize(h)), nil
+}
+
+// Fill creates plots in dir until it is full, leaving opts.Reserve bytes
+// free, and returns their paths, see PlotSeries.
+func Fill(dir string, opts FillOptions) ([]string, error) {
+	total, err := FillCount(dir, opts.KValue, opts.Reserve, opts.PlotOptions)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, ErrInsufficientSpace
+	}
+	return PlotSeries(dir, total, opts)
+}
+
+// PlotSeries creates count plots in destDir and returns their paths. With
+// opts.TmpDir set, finished plots are moved to destDir one at a time while
+// the next ones are created, so the temporary directory stays busy during
+// transfers. It needs room for opts.Concurrency+2 plots: those being created,
+// one waiting to move and one being moved. After a plot fails no more are
+// started, and the plots finished so far are returned along with the error.
+// Plots that fail to move are left in opts.TmpDir.
+func PlotSeries(destDir string, count int, opts FillOptions) ([]string, error) {
+	h, err := newPlotHeader(opts.KValue, opts.PlotOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreeSpace(opts.Store, destDir, uint64(count)*plotFileSize(h)); err != nil {
+		return nil, err
+	}
+
+	type transfer struct {
+		index  int
+		path   string
+		header *Header
+		start  time.Time
+	}
+
+	workers := min(max(opts.Concurrency, 1), count)
+	jobs := make(chan int)
+	transfers := make(chan transfer, 1)
+	var (
+		mu    sync.Mutex
+		paths []string
+		errs  []error
+		wg    sync.WaitGroup
+	)
+	report := func(p FillProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p.Done {
+			if p.Err != nil {
+				errs = append(errs, p.Err)
+			} else {
+				paths = append(paths, p.Path)
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(p)
+		}
+	}
+	finish := func(index int, path string, err error, start time.Time) {
+		report(FillProgress{Index: index, Total: count, Done: true, Path: path, Err: err, Duration: time.Since(start)})
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) != 0
+	}
+
+	moved := make(chan struct{})
+	go func() {
+		defer close(moved)
+		for t := range transfers {
+			path, err := unstagePlot(opts.Store, t.path, t.header, destDir)
+			finish(t.index, path, err, t.start)
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report(FillProgress{Index: i, Total: count})
+				start := time.Now()
+
+				if opts.TmpDir == "" {
+					path, err := PlotWithOptions(destDir, opts.KValue, opts.PlotOptions)
+					finish(i, path, err, start)
+					continue
+				}
+
+				h, err := newPlotHeader(opts.KValue, opts.PlotOptions)
+				if err != nil {
+					finish(i, "", err, start)
+					continue
+				}
+				path, err := stagePlot(h, opts.PlotOptions)
+				if err != nil {
+					finish(i, "", err, start)
+					continue
+				}
+				transfers <- transfer{index: i, path: path, header: h, start: start}
+			}
+		}()
+	}
+	for i := 1; i <= count && !failed(); i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(transfers)
+	<-moved
+
+	return paths, errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"errors"
+	"testing"
+)
+
+// limitedStore is a MemStore whose directories hold a fixed number of bytes.
+type limitedStore struct {
+	*MemStore
+	capacity map[string]uint64
+}
+
+func (s limitedStore) FreeSpace(dir string) (uint64, error) {
+	names, err := s.List(dir)
+	if err != nil {
+		return 0, err
+	}
+	var used uint64
+	for _, name := range names {
+		info, err := s.Stat(name)
+		if err != nil {
+			return 0, err
+		}
+		used += uint64(info.Size())
+	}
+	return s.capacity[memName(dir)] - min(used, s.capacity[memName(dir)]), nil
+}
+
+func TestFill(t *testing.T) {
+	opts := PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	}
+	h, err := newPlotHeader(1, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := plotFileSize(h)
+	store := limitedStore{NewMemStore(), map[string]uint64{"farm": 3*size + size/2}}
+	opts.Store = store
+
+	for _, c := range []struct {
+		reserve uint64
+		want    int
+	}{
+		{0, 3},
+		{size, 2},
+		{3 * size, 0},
+		{4 * size, 0},
+	} {
+		if n, err := FillCount("farm", 1, c.reserve, opts); err != nil || n != c.want {
+			t.Errorf("FillCount with %d bytes reserved is %d, want %d: %v", c.reserve, n, c.want, err)
+		}
+	}
+
+	var started, done int
+	paths, err := Fill("farm", FillOptions{
+		PlotOptions: opts,
+		KValue:      1,
+		Reserve:     size,
+		Concurrency: 2,
+		Progress: func(p FillProgress) {
+			if p.Total != 2 || p.Index < 1 || p.Index > 2 {
+				t.Errorf("Unexpected progress %+v", p)
+			}
+			if p.Done {
+				done++
+			} else {
+				started++
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to fill: %v", err)
+	}
+	if len(paths) != 2 || started != 2 || done != 2 {
+		t.Errorf("Expected 2 plots, got %d with %d started and %d done", len(paths), started, done)
+	}
+	if names, _ := store.List("farm"); len(names) != 2 {
+		t.Errorf("Expected 2 plot files, got %v", names)
+	}
+
+	// Another plot no longer fits above the reserve
+	if _, err := Fill("farm", FillOptions{PlotOptions: opts, KValue: 1, Reserve: size}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("Expected ErrInsufficientSpace, got %v", err)
+	}
+	if _, err := PlotSeries("farm", 2, FillOptions{PlotOptions: opts, KValue: 1}); !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("Expected ErrInsufficientSpace, got %v", err)
+	}
+	if names, _ := store.List("farm"); len(names) != 2 {
+		t.Errorf("Expected no more plot files, got %v", names)
+	}
+}
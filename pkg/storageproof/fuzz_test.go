@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// The seed corpora of these targets are in testdata/fuzz. Run a target with
+// e.g. go test -fuzz FuzzHeader ./pkg/storageproof
+
+func FuzzHeader(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var h Header
+		if err := h.UnmarshalBinary(data); err != nil {
+			return
+		}
+		if h.Size() > len(data) {
+			t.Fatalf("Header size %d exceeds its %d bytes", h.Size(), len(data))
+		}
+
+		// ReadHeader agrees with UnmarshalBinary on anything it accepts
+		r := bytes.NewReader(data)
+		if read, err := ReadHeader(r); err == nil {
+			if read.Size() != h.Size() || int(r.Size())-r.Len() != h.Size() {
+				t.Fatalf("ReadHeader consumed %d bytes, header is %d", int(r.Size())-r.Len(), h.Size())
+			}
+		}
+
+		// A header we wrote reads back the same
+		b, err := h.MarshalBinary()
+		if err != nil {
+			return
+		}
+		var again Header
+		if err := again.UnmarshalBinary(b); err != nil {
+			t.Fatalf("Failed to read back a marshalled header: %v", err)
+		}
+		h.size, again.size = 0, 0
+		if !reflect.DeepEqual(h, again) {
+			t.Fatalf("Header changed in a round trip: %+v != %+v", h, again)
+		}
+	})
+}
+
+func FuzzKeyEntry(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var ke KeyEntry
+		if err := ke.UnmarshalBinary(data); err != nil {
+			if len(data) >= KeyEntrySize {
+				t.Fatalf("Failed to read a %d byte key entry: %v", len(data), err)
+			}
+			return
+		}
+		b, err := ke.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, data[:KeyEntrySize]) {
+			t.Fatalf("Key entry changed in a round trip")
+		}
+	})
+}
+
+func FuzzSolutionJSON(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s Solution
+		if err := json.Unmarshal(data, &s); err != nil {
+			return
+		}
+
+		// Every solution the canonical encodings accept survives them
+		b, err := s.MarshalBinary()
+		if err != nil {
+			return
+		}
+		var fromBinary Solution
+		if err := fromBinary.UnmarshalBinary(b); err != nil {
+			t.Fatalf("Failed to read back a binary solution: %v", err)
+		}
+		c, err := s.MarshalCBOR()
+		if err != nil {
+			t.Fatalf("Binary solution can't be encoded as CBOR: %v", err)
+		}
+		var fromCBOR Solution
+		if err := fromCBOR.UnmarshalCBOR(c); err != nil {
+			t.Fatalf("Failed to read back a CBOR solution: %v", err)
+		}
+		if !reflect.DeepEqual(fromBinary, fromCBOR) {
+			t.Fatalf("Binary and CBOR encodings disagree: %+v != %+v", fromBinary, fromCBOR)
+		}
+	})
+}
+
+func FuzzSolutionBinary(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s Solution
+		if err := s.UnmarshalBinary(data); err != nil {
+			return
+		}
+		// The binary encoding is canonical
+		b, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal a decoded solution: %v", err)
+		}
+		if !bytes.Equal(b, data) {
+			t.Fatalf("Solution encoding is not canonical")
+		}
+	})
+}
+
+func FuzzSolutionCBOR(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s Solution
+		if err := s.UnmarshalCBOR(data); err != nil {
+			return
+		}
+		if _, err := s.MarshalCBOR(); err != nil {
+			t.Fatalf("Failed to marshal a decoded solution: %v", err)
+		}
+	})
+}
+
+func FuzzVerify(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var s Solution
+		if err := json.Unmarshal(data, &s); err != nil {
+			return
+		}
+		_, _ = s.Verify()
+		_, _ = s.VerifyWithOptions(VerifyOptions{HashPolicy: PermissiveHashPolicy})
+	})
+}
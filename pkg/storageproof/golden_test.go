@@ -0,0 +1,296 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// The golden plot holds 1000 Ed25519 key seeds, read in order from
+// SHAKE256("storageproof golden plot"), hashed with SHAKE256 and bound to
+// goldenFarmerKey and goldenPoolKey, with fixed metadata. See
+// testdata/golden/README.md.
+var (
+	goldenPlot      = filepath.Join("testdata", "golden", "sp2-golden.plot")
+	goldenSolutions = filepath.Join("testdata", "golden", "solutions.json")
+	goldenFarmerKey = []byte("golden farmer")
+	goldenPoolKey   = []byte("golden pool")
+)
+
+// goldenCase is a solution and how verifying it must turn out.
+type goldenCase struct {
+	Name     string          `json:"name"`
+	Solution json.RawMessage `json:"solution"`
+	// FarmerKey, AllowWeakHash and PlotID are the verify options, as the
+	// CLI flags.
+	FarmerKey     string `json:"farmer_key,omitempty"`
+	AllowWeakHash bool   `json:"allow_weak_hash,omitempty"`
+	PlotID        string `json:"plot_id,omitempty"`
+	// Result is valid, invalid or malformed, as printed by plotlib verify.
+	Result string `json:"result"`
+}
+
+func goldenRand(label string) *sha3.SHAKE {
+	rng := sha3.NewSHAKE256()
+	_, _ = rng.Write([]byte(label))
+	return rng
+}
+
+func TestGoldenPlot(t *testing.T) {
+	dir := t.TempDir()
+	path, err := PlotWithOptions(dir, 1, PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+		FarmerKey:  goldenFarmerKey,
+		PoolKey:    goldenPoolKey,
+		Metadata: &Metadata{
+			CreatedAt:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			Hostname:       "golden",
+			PlotterVersion: "plotlib " + libVersion,
+			Labels:         map[string]string{"purpose": "test vectors"},
+		},
+		Rand: goldenRand("storageproof golden plot"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := os.WriteFile(goldenPlot, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenPlot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Plot differs from %s; if the format changed on purpose, rerun with -update", goldenPlot)
+	}
+}
+
+func TestGoldenSolutions(t *testing.T) {
+	cases := goldenCases(t)
+	got, err := json.MarshalIndent(cases, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.WriteFile(goldenSolutions, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenSolutions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Solutions differ from %s; if the encoding changed on purpose, rerun with -update", goldenSolutions)
+	}
+
+	// Verify the committed cases, not the ones just generated
+	var golden []goldenCase
+	if err := json.Unmarshal(want, &golden); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range golden {
+		if result := verifyGolden(t, c); result != c.Result {
+			t.Errorf("%s: expected %s, got %s", c.Name, c.Result, result)
+		}
+	}
+}
+
+// verifyGolden verifies a case the way plotlib verify does.
+func verifyGolden(t *testing.T, c goldenCase) string {
+	var s Solution
+	if err := json.Unmarshal(c.Solution, &s); err != nil {
+		return "malformed"
+	}
+	opts := VerifyOptions{}
+	if c.AllowWeakHash {
+		opts.HashPolicy = PermissiveHashPolicy
+	}
+	if c.FarmerKey != "" {
+		var err error
+		if opts.FarmerKey, err = hex.DecodeString(c.FarmerKey); err != nil {
+			t.Fatalf("%s: invalid farmer key: %v", c.Name, err)
+		}
+	}
+	if c.PlotID != "" {
+		opts.PlotRegistered = func(id PlotID) bool { return id.String() == c.PlotID }
+	}
+	valid, err := s.VerifyWithOptions(opts)
+	switch {
+	case errors.Is(err, ErrMalformedSolution):
+		return "malformed"
+	case err != nil || !valid:
+		return "invalid"
+	}
+	return "valid"
+}
+
+// goldenCases derives the golden solutions from the golden plot and fixed
+// key seeds. Signatures of every scheme are deterministic.
+func goldenCases(t *testing.T) []goldenCase {
+	var cases []goldenCase
+	add := func(name string, s any, result string, modify func(*goldenCase)) {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := goldenCase{Name: name, Solution: b, Result: result}
+		if modify != nil {
+			modify(&c)
+		}
+		cases = append(cases, c)
+	}
+	weak := func(c *goldenCase) { c.AllowWeakHash = true }
+
+	pc, err := LoadPlots([]string{goldenPlot}, false)
+	if err != nil || len(pc.Plots) != 1 {
+		t.Fatalf("Failed to load %s: %v", goldenPlot, err)
+	}
+	challenges := goldenRand("storageproof golden challenges")
+	var found *Solution
+	for i := range 3 {
+		challenge := make([]byte, 32)
+		_, _ = challenges.Read(challenge)
+		s, err := pc.LookUp(challenge)
+		if err != nil {
+			t.Fatalf("Failed to look up challenge %d: %v", i, err)
+		}
+		add("plot lookup "+hex.EncodeToString(challenge[:4]), s, "valid", weak)
+		found = s
+	}
+
+	add("bound to the farmer", found, "valid", func(c *goldenCase) {
+		c.AllowWeakHash, c.FarmerKey = true, hex.EncodeToString(goldenFarmerKey)
+	})
+	add("bound to another farmer", found, "invalid", func(c *goldenCase) {
+		c.AllowWeakHash, c.FarmerKey = true, hex.EncodeToString([]byte("another farmer"))
+	})
+	add("weak hash under the default policy", found, "invalid", nil)
+
+	var plotID PlotID
+	for id := range pc.Plots {
+		plotID = id
+	}
+	add("key in the registered plot", found, "valid", func(c *goldenCase) {
+		c.AllowWeakHash, c.PlotID = true, plotID.String()
+	})
+	add("key in an unregistered plot", found, "invalid", func(c *goldenCase) {
+		c.AllowWeakHash, c.PlotID = true, PlotID{}.String()
+	})
+
+	r, err := found.raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := *found
+	sig := bytes.Clone(r.Signature)
+	sig[0] ^= 1
+	tampered.Signature = encodeAscii85(sig)
+	add("tampered signature", tampered, "invalid", weak)
+
+	tampered = *found
+	tampered.FarmerKey = encodeAscii85([]byte("another farmer"))
+	add("farmer key swapped", tampered, "invalid", weak)
+
+	tampered = *found
+	proof := *found.Proof
+	proof.Index++
+	tampered.Proof = &proof
+	add("inclusion proof of another key", tampered, "invalid", weak)
+
+	tampered = *found
+	tampered.Proof = nil
+	add("no inclusion proof for a registered plot", tampered, "invalid", func(c *goldenCase) {
+		c.AllowWeakHash, c.PlotID = true, plotID.String()
+	})
+
+	// Another key signs the hash of the registered plot's key, lifting its
+	// proof and dropping the hash parameters that would give it away
+	seed := make([]byte, SchemeEd25519.SeedSize())
+	_, _ = goldenRand("storageproof golden forger").Read(seed)
+	forger, err := SchemeEd25519.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := NewSolutionWithSigner(r.Hash, found.Distance, forger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.PlotID, forged.Proof = found.PlotID, found.Proof
+	add("foreign key with a registered plot proof", forged, "invalid", func(c *goldenCase) {
+		c.AllowWeakHash, c.PlotID = true, plotID.String()
+	})
+
+	tampered = *found
+	tampered.Signature = "not ascii85 {}"
+	add("signature not ascii85", tampered, "malformed", weak)
+
+	tampered = *found
+	tampered.PublicKey = encodeAscii85(r.PublicKey[:16])
+	add("short public key", tampered, "malformed", weak)
+
+	// A solution for every scheme and hash, the plot hash being the challenge
+	for _, c := range []struct {
+		scheme SchemeID
+		params *HashParams
+	}{
+		{SchemeMLDSA87, nil},
+		{SchemeMLDSA87, &HashParams{Algorithm: HashSHA3_256, Salt: defaultSalt}},
+		{SchemeMLDSA65, &HashParams{Algorithm: HashBLAKE2b, Salt: defaultSalt}},
+		{SchemeMLDSA44, &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt}},
+		{SchemeEd25519, &HashParams{Algorithm: HashArgon2id, Time: 1, Memory: 64 * 1024, Threads: 4, Salt: defaultSalt}},
+	} {
+		seed := make([]byte, c.scheme.SeedSize())
+		_, _ = goldenRand("storageproof golden " + c.scheme.String()).Read(seed)
+		sk, err := c.scheme.NewKeyFromSeed(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, err := sk.Public().MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		name := c.scheme.String() + " without hash parameters"
+		hash := sha3.Sum256(pk)
+		if c.params != nil {
+			name = c.scheme.String() + " with " + c.params.Algorithm.String()
+			if hash, err = c.params.Sum(pk); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := NewSolutionWithSigner(hash[:], 0, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.HashParams = c.params
+		add(name, s, "valid", weak)
+	}
+
+	add("unknown scheme", map[string]any{"hash": found.Hash, "distance": 0, "scheme": 9}, "malformed", weak)
+	add("not a solution", []int{1, 2, 3}, "malformed", nil)
+	return cases
+}
@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// HashAlgorithm identifies the function used to hash public keys into the
+// plot lookup table.
+type HashAlgorithm uint8
+
+const (
+	// HashArgon2id is the memory-hard default, and what every plot made
+	// before hash parameters were recorded uses.
+	HashArgon2id HashAlgorithm = 0
+	HashSHAKE256 HashAlgorithm = 1
+	HashSHA3_256 HashAlgorithm = 2
+	HashBLAKE2b  HashAlgorithm = 3
+)
+
+var hashAlgorithmNames = map[HashAlgorithm]string{
+	HashArgon2id: "argon2id",
+	HashSHAKE256: "shake256",
+	HashSHA3_256: "sha3-256",
+	HashBLAKE2b:  "blake2b-256",
+}
+
+const defaultSalt = "storageproof"
+
+// Upper bounds on Argon2id parameters, so a solution can't make its verifier
+// spend unbounded time or memory recomputing its hash.
+const (
+	maxHashTime   = 64
+	maxHashMemory = 4 << 20 // 4 GiB in KiB
+	// maxPolicyCost is how many times more expensive than the defaults
+	// DefaultHashPolicy lets a solution's hash be.
+	maxPolicyCost = 16
+)
+
+// HashParams describes how public keys are hashed into a plot. They are stored
+// in the plot header and carried in solutions so verifiers can recompute the
+// hash. Time, Memory and Threads only apply to Argon2id.
+type HashParams struct {
+	Algorithm HashAlgorithm `json:"algorithm"`
+	Time      uint32        `json:"time,omitempty"`
+	Memory    uint32        `json:"memory,omitempty"` // in KiB
+	Threads   uint8         `json:"threads,omitempty"`
+	Salt      string        `json:"salt"`
+}
+
+// DefaultHashParams returns the Argon2id parameters plots have always used.
+func DefaultHashParams() HashParams {
+	return HashParams{
+		Algorithm: HashArgon2id,
+		Time:      1,
+		Memory:    64 * 1024,
+		Threads:   4,
+		Salt:      defaultSalt,
+	}
+}
+
+// HashPolicy decides whether hash parameters declared by a solution are
+// strong enough to be accepted.
+type HashPolicy func(p HashParams) error
+
+// DefaultHashPolicy only accepts Argon2id at least as strong as
+// DefaultHashParams, and at most 16 times as expensive to compute.
+func DefaultHashPolicy(p HashParams) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	def := DefaultHashParams()
+	if p.Algorithm != HashArgon2id {
+		return fmt.Errorf("hash algorithm %s is too weak", p.Algorithm)
+	}
+	if p.Time < def.Time || p.Memory < def.Memory {
+		return errors.New("argon2id parameters are too weak")
+	}
+	if uint64(p.Time)*uint64(p.Memory) > maxPolicyCost*uint64(def.Time)*uint64(def.Memory) {
+		return errors.New("argon2id parameters are too expensive to verify")
+	}
+	return nil
+}
+
+// PermissiveHashPolicy accepts any valid hash parameters, which is useful
+// for test networks that plot with fast hashes.
+func PermissiveHashPolicy(p HashParams) error {
+	return p.Validate()
+}
+
+// HashAlgorithmByName returns the hash algorithm with the given name, e.g. "shake256".
+func HashAlgorithmByName(name string) (HashAlgorithm, error) {
+	for a, n := range hashAlgorithmNames {
+		if n == name {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown hash algorithm %q", name)
+}
+
+func (a HashAlgorithm) String() string {
+	if n, ok := hashAlgorithmNames[a]; ok {
+		return n
+	}
+	return fmt.Sprintf("hash(%d)", uint8(a))
+}
+
+func (a HashAlgorithm) MarshalText() ([]byte, error) {
+	if _, ok := hashAlgorithmNames[a]; !ok {
+		return nil, fmt.Errorf("unknown hash algorithm %d", uint8(a))
+	}
+	return []byte(a.String()), nil
+}
+
+func (a *HashAlgorithm) UnmarshalText(text []byte) error {
+	alg, err := HashAlgorithmByName(string(text))
+	if err != nil {
+		return err
+	}
+	*a = alg
+	return nil
+}
+
+// Validate checks that the parameters can be used to compute a hash.
+func (p HashParams) Validate() error {
+	if _, ok := hashAlgorithmNames[p.Algorithm]; !ok {
+		return fmt.Errorf("unknown hash algorithm %d", uint8(p.Algorithm))
+	}
+	if len(p.Salt) > 0xffff {
+		return errors.New("hash salt too long")
+	}
+	if p.Algorithm == HashArgon2id {
+		if p.Time < 1 || p.Threads < 1 {
+			return errors.New("argon2id time and threads must be at least 1")
+		}
+		if p.Memory < 8*uint32(p.Threads) {
+			return errors.New("argon2id memory must be at least 8 KiB per thread")
+		}
+		if p.Time > maxHashTime || p.Memory > maxHashMemory {
+			return fmt.Errorf("argon2id time must be at most %d and memory at most %d KiB", maxHashTime, maxHashMemory)
+		}
+	}
+	return nil
+}
+
+// Sum hashes a packed public key into a 32-byte plot table hash.
+func (p HashParams) Sum(pkBytes []byte) ([32]byte, error) {
+	return p.sum(pkBytes, []byte(p.Salt))
+}
+
+// SumBound is Sum with the salt bound to a farmer and optional pool key, so
+// the resulting hashes are only valid for plots owned by that farmer. Without
+// a farmer key it is the same as Sum.
+func (p HashParams) SumBound(pkBytes, farmerKey, poolKey []byte) ([32]byte, error) {
+	if len(farmerKey) == 0 {
+		if len(poolKey) != 0 {
+			return [32]byte{}, errors.New("pool key requires a farmer key")
+		}
+		return p.Sum(pkBytes)
+	}
+	if len(farmerKey) > 0xffff || len(poolKey) > 0xffff {
+		return [32]byte{}, errors.New("farmer or pool key too long")
+	}
+
+	// Digest the keys so the salt stays short regardless of key size
+	h := sha3.New256()
+	_, _ = h.Write([]byte("storageproof farmer binding"))
+	var lengths [4]byte
+	binary.LittleEndian.PutUint16(lengths[0:2], uint16(len(farmerKey)))
+	binary.LittleEndian.PutUint16(lengths[2:4], uint16(len(poolKey)))
+	_, _ = h.Write(lengths[:])
+	_, _ = h.Write(farmerKey)
+	_, _ = h.Write(poolKey)
+
+	return p.sum(pkBytes, h.Sum([]byte(p.Salt)))
+}
+
+func (p HashParams) sum(pkBytes, salt []byte) ([32]byte, error) {
+	var out [32]byte
+	if err := p.Validate(); err != nil {
+		return out, err
+	}
+
+	switch p.Algorithm {
+	case HashArgon2id:
+		copy(out[:], argon2.IDKey(pkBytes, salt, p.Time, p.Memory, p.Threads, 32))
+	case HashSHAKE256:
+		h := sha3.NewSHAKE256()
+		_, _ = h.Write(salt)
+		_, _ = h.Write(pkBytes)
+		_, _ = h.Read(out[:])
+	case HashSHA3_256:
+		h := sha3.New256()
+		_, _ = h.Write(salt)
+		_, _ = h.Write(pkBytes)
+		copy(out[:], h.Sum(nil))
+	case HashBLAKE2b:
+		h, err := blake2b.New256(nil)
+		if err != nil {
+			return out, err
+		}
+		_, _ = h.Write(salt)
+		_, _ = h.Write(pkBytes)
+		copy(out[:], h.Sum(nil))
+	}
+	return out, nil
+}
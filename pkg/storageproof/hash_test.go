@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestVerifyRecomputesHash(t *testing.T) {
+	params := HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt}
+
+	seed := make([]byte, SchemeEd25519.SeedSize())
+	_, err := rand.Read(seed)
+	if err != nil {
+		t.Fatalf("Failed to create seed: %v", err)
+	}
+	sk, err := SchemeEd25519.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	pkBytes, err := sk.Public().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	hash, err := params.Sum(pkBytes)
+	if err != nil {
+		t.Fatalf("Failed to hash public key: %v", err)
+	}
+
+	solution, err := NewSolutionWithSigner(hash[:], 0, sk)
+	if err != nil {
+		t.Fatalf("Failed to create solution: %v", err)
+	}
+	solution.HashParams = &params
+
+	// SHAKE256 is too weak for the default policy
+	_, err = solution.Verify()
+	if err == nil {
+		t.Errorf("Expected the default policy to reject SHAKE256")
+	}
+
+	permissive := VerifyOptions{HashPolicy: PermissiveHashPolicy}
+	valid, err := solution.VerifyWithOptions(permissive)
+	if err != nil {
+		t.Fatalf("Failed to verify solution: %v", err)
+	}
+	if !valid {
+		t.Errorf("Expected solution to be valid, but it was not")
+	}
+
+	// A correctly signed hash that isn't the hash of the public key must fail
+	other := make([]byte, 32)
+	_, err = rand.Read(other)
+	if err != nil {
+		t.Fatalf("Failed to create hash: %v", err)
+	}
+	forged, err := NewSolutionWithSigner(other, 0, sk)
+	if err != nil {
+		t.Fatalf("Failed to create solution: %v", err)
+	}
+	forged.HashParams = &params
+	valid, err = forged.VerifyWithOptions(permissive)
+	if err != nil {
+		t.Fatalf("Failed to verify solution: %v", err)
+	}
+	if valid {
+		t.Errorf("Expected solution with a foreign hash to be invalid, but it was valid")
+	}
+}
+
+func TestVerifyFarmerBinding(t *testing.T) {
+	params := HashParams{Algorithm: HashBLAKE2b, Salt: defaultSalt}
+	farmerKey := []byte("farmer public key")
+	header := &Header{Version: Version, HashParams: params, FarmerKey: farmerKey}
+
+	seed := make([]byte, SchemeEd25519.SeedSize())
+	_, err := rand.Read(seed)
+	if err != nil {
+		t.Fatalf("Failed to create seed: %v", err)
+	}
+	sk, err := SchemeEd25519.NewKeyFromSeed(seed)
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	pkBytes, err := sk.Public().MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	hash, err := header.hashKey(pkBytes)
+	if err != nil {
+		t.Fatalf("Failed to hash public key: %v", err)
+	}
+
+	solution, err := NewSolutionWithSigner(hash[:], 0, sk)
+	if err != nil {
+		t.Fatalf("Failed to create solution: %v", err)
+	}
+	solution.bind(header)
+
+	opts := VerifyOptions{HashPolicy: PermissiveHashPolicy, FarmerKey: farmerKey}
+	valid, err := solution.VerifyWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Failed to verify solution: %v", err)
+	}
+	if !valid {
+		t.Errorf("Expected bound solution to be valid, but it was not")
+	}
+
+	// Claiming the plot for another farmer breaks the hash binding
+	solution.FarmerKey = encodeAscii85([]byte("someone else"))
+	valid, err = solution.VerifyWithOptions(VerifyOptions{HashPolicy: PermissiveHashPolicy})
+	if err != nil {
+		t.Fatalf("Failed to verify solution: %v", err)
+	}
+	if valid {
+		t.Errorf("Expected solution claimed by another farmer to be invalid, but it was valid")
+	}
+
+	// So does expecting a different farmer than the one the plot is bound to
+	solution.bind(header)
+	opts.FarmerKey = []byte("someone else")
+	valid, err = solution.VerifyWithOptions(opts)
+	if err != nil {
+		t.Fatalf("Failed to verify solution: %v", err)
+	}
+	if valid {
+		t.Errorf("Expected solution for an unexpected farmer to be invalid, but it was valid")
+	}
+}
+
+func TestHashPolicyBounds(t *testing.T) {
+	def := DefaultHashParams()
+	if err := DefaultHashPolicy(def); err != nil {
+		t.Errorf("Expected the default parameters to be accepted: %v", err)
+	}
+
+	weak := def
+	weak.Memory /= 2
+	if err := DefaultHashPolicy(weak); err == nil {
+		t.Errorf("Expected weaker parameters to be rejected")
+	}
+
+	// Parameters a verifier can't afford to recompute
+	for _, p := range []HashParams{
+		{Algorithm: HashArgon2id, Time: 0xffffffff, Memory: 0xffffffff, Threads: 4, Salt: defaultSalt},
+		{Algorithm: HashArgon2id, Time: maxHashTime + 1, Memory: def.Memory, Threads: 4, Salt: defaultSalt},
+		{Algorithm: HashArgon2id, Time: 1, Memory: maxHashMemory + 1, Threads: 4, Salt: defaultSalt},
+		{Algorithm: HashArgon2id, Time: maxPolicyCost + 1, Memory: def.Memory, Threads: 4, Salt: defaultSalt},
+	} {
+		if err := DefaultHashPolicy(p); err == nil {
+			t.Errorf("Expected time %d and memory %d to be rejected", p.Time, p.Memory)
+		}
+	}
+	if err := PermissiveHashPolicy(HashParams{Algorithm: HashArgon2id, Time: 0xffffffff, Memory: 0xffffffff, Threads: 4}); err == nil {
+		t.Errorf("Expected the permissive policy to reject unbounded parameters")
+	}
+
+	// Verification must fail before hashing anything
+	sk, err := SchemeEd25519.NewKeyFromSeed(make([]byte, SchemeEd25519.SeedSize()))
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	solution, err := NewSolutionWithSigner(make([]byte, 32), 0, sk)
+	if err != nil {
+		t.Fatalf("Failed to create solution: %v", err)
+	}
+	solution.HashParams = &HashParams{Algorithm: HashArgon2id, Time: 0xffffffff, Memory: 0xffffffff, Threads: 4, Salt: defaultSalt}
+	if valid, err := solution.Verify(); valid || err == nil {
+		t.Errorf("Expected a solution with unbounded hash parameters to be rejected")
+	}
+}
@@ -4,132 +4,312 @@
 package storageproof
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strings"
-
-	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"sync"
 )
 
+// headerReadSize is how much of a plot is read to get its header, enough
+// for any header written so far.
+const headerReadSize = 4096
+
+// PlotCollection holds the loaded plots by ID, so each plot is held once
+// however many copies of it there are.
 type PlotCollection struct {
-	Plots map[string]*PlotInfo
+	Plots map[PlotID]*PlotInfo
+	// Duplicates lists the plot files that were skipped as copies of
+	// loaded plots, or as sharing keys with them.
+	Duplicates []DuplicatePlot
 }
 
 type PlotInfo struct {
 	*Header
 	KeyEntries []KeyEntry
+	// Path is the name of the plot file in its store.
+	Path string
+
+	store PlotStore // where the plot was loaded from, OSStore when nil
+
+	treeMu sync.Mutex
+	tree   *merkleTree // built on the first lookup the plot wins
+}
+
+// inclusionProof returns the proof that key entry index is in the plot.
+func (p *PlotInfo) inclusionProof(index int) *InclusionProof {
+	p.treeMu.Lock()
+	if p.tree == nil {
+		p.tree = newMerkleTree(p.KeyEntries)
+	}
+	path := p.tree.path(p.KeyEntries, index)
+	p.treeMu.Unlock()
+
+	raw := &rawInclusionProof{Index: uint32(index), NumKeys: uint32(len(p.KeyEntries)), Path: path}
+	return raw.proof()
+}
+
+// TotalKeys returns the number of keys across all loaded plots.
+func (pc *PlotCollection) TotalKeys() uint64 {
+	var total uint64
+	for _, plot := range pc.Plots {
+		total += uint64(plot.NumKeys)
+	}
+	return total
+}
+
+// Merge adds the plots of other to pc, so one collection can span several
+// stores, such as local disks and object storage. Plots pc already holds are
+// kept, and the copies in other recorded as duplicates.
+func (pc *PlotCollection) Merge(other *PlotCollection) {
+	pc.Duplicates = append(pc.Duplicates, other.Duplicates...)
+	for id, plot := range other.Plots {
+		if first, ok := pc.Plots[id]; ok {
+			pc.Duplicates = append(pc.Duplicates, DuplicatePlot{Path: plot.Path, ID: id, Of: first.Path, SharedKeys: len(plot.KeyEntries)})
+			continue
+		}
+		pc.Plots[id] = plot
+	}
+}
+
+// LoadOptions controls LoadPlotsWithOptions.
+type LoadOptions struct {
+	// Store holds the plots, OSStore when nil.
+	Store PlotStore
+	// CacheDir, if set, keeps an index of every plot's header and key table,
+	// so plots whose size and modification time are unchanged load without
+	// being read. Only plots in OSStore and S3Store are cached.
+	CacheDir string
+	// RebuildCache rereads every plot and replaces its index, removing the
+	// indexes of plots no longer found in the paths loaded.
+	RebuildCache bool
+	// SkipDuplicateKeys also skips plots sharing any key with a plot loaded
+	// before them. It indexes every key while loading, which takes memory
+	// in proportion to them.
+	SkipDuplicateKeys bool
+	Verbose           bool
 }
 
 func LoadPlots(paths []string, verbose bool) (*PlotCollection, error) {
+	return LoadPlotsWithOptions(paths, LoadOptions{Verbose: verbose})
+}
+
+// LoadPlotsWithOptions loads the plot files named sp*.plot in and below each
+// of paths from opts.Store. Of several copies of a plot, identified by their
+// ID, the first is loaded and the others are listed in Duplicates. Paths that
+// can't be listed and plots that can't be read are skipped, and their errors
+// returned joined along with the plots that did load.
+func LoadPlotsWithOptions(paths []string, opts LoadOptions) (*PlotCollection, error) {
+	store := storeOrOS(opts.Store)
 	pc := &PlotCollection{
-		Plots: make(map[string]*PlotInfo),
+		Plots: make(map[PlotID]*PlotInfo),
+	}
+	var keys keyIndex
+	if opts.SkipDuplicateKeys {
+		keys = make(keyIndex)
 	}
 
+	var errs []error
 	for _, path := range paths {
-		_ = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+		names, err := store.List(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error listing plots in %s: %w", path, err))
+			continue
+		}
+		if opts.CacheDir != "" && opts.RebuildCache {
+			if err := pruneCache(store, path, names, opts.CacheDir); err != nil {
+				errs = append(errs, fmt.Errorf("error pruning the index cache: %w", err))
+			}
+		}
+		for _, filePath := range names {
+			name := filepath.Base(filePath)
+			if !strings.HasPrefix(name, "sp") || !strings.HasSuffix(name, ".plot") {
+				continue
+			}
+			if opts.Verbose {
+				fmt.Printf("Loading plot file: %s\n", filePath)
 			}
 
-			if !info.IsDir() && strings.HasPrefix(info.Name(), "sp") && strings.HasSuffix(info.Name(), ".plot") {
-				if verbose {
-					fmt.Printf("Loading plot file: %s\n", filePath)
-				}
-
-				file, err := os.Open(filePath)
-				if err != nil {
-					return err
+			var plot *PlotInfo
+			if opts.CacheDir != "" {
+				plot, err = loadCachedPlot(store, filePath, opts.CacheDir, opts.RebuildCache)
+			} else {
+				plot, err = loadPlot(store, filePath)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error loading plot %s: %w", filePath, err))
+				continue
+			}
+			if first, ok := pc.Plots[plot.ID]; ok {
+				if opts.Verbose {
+					fmt.Printf("Skipping %s, a copy of %s\n", filePath, first.Path)
 				}
-				defer func(file *os.File) {
-					_ = file.Close()
-				}(file)
-
-				headerBytes := make([]byte, 40)
-				_, err = file.Read(headerBytes)
-				if err != nil {
-					return err
+				pc.Duplicates = append(pc.Duplicates, DuplicatePlot{Path: filePath, ID: plot.ID, Of: first.Path, SharedKeys: len(plot.KeyEntries)})
+				continue
+			}
+			if keys != nil {
+				if shared, of := keys.shared(plot); shared != 0 {
+					if opts.Verbose {
+						fmt.Printf("Skipping %s, which shares %d keys with %s\n", filePath, shared, of.Path)
+					}
+					pc.Duplicates = append(pc.Duplicates, DuplicatePlot{Path: filePath, ID: plot.ID, Of: of.Path, SharedKeys: shared})
+					continue
 				}
+				keys.add(plot)
+			}
+			pc.Plots[plot.ID] = plot
+		}
+	}
 
-				header := &Header{}
-				err = header.UnmarshalBinary(headerBytes)
-				if err != nil {
-					return err
-				}
+	return pc, errors.Join(errs...)
+}
 
-				keyEntries := make([]KeyEntry, header.NumKeys)
-				for i := uint32(0); i < header.NumKeys; i++ {
-					keBytes := make([]byte, 40)
-					_, err = file.Read(keBytes)
-					if err != nil {
-						return err
-					}
-					err = keyEntries[i].UnmarshalBinary(keBytes)
-					if err != nil {
-						return err
-					}
-				}
+// ReadPlotHeader reads the header of the plot name in store, or the local
+// filesystem when store is nil, without reading its key table.
+func ReadPlotHeader(store PlotStore, name string) (*Header, error) {
+	file, err := storeOrOS(store).Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file PlotFile) {
+		_ = file.Close()
+	}(file)
+	return ReadHeader(bufio.NewReaderSize(fileReader(file), headerReadSize))
+}
 
-				pc.Plots[filePath] = &PlotInfo{
-					Header:     header,
-					KeyEntries: keyEntries,
-				}
-			}
+// loadPlot reads the header and key table of the plot at filePath.
+func loadPlot(store PlotStore, filePath string) (*PlotInfo, error) {
+	plot, _, err := readPlot(store, filePath)
+	return plot, err
+}
 
-			return nil
-		})
+// readPlot is loadPlot, also returning the key table as stored. The table is
+// read at once, so remote stores fetch it in a single request.
+func readPlot(store PlotStore, filePath string) (*PlotInfo, []byte, error) {
+	file, err := store.Open(filePath)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer func(file PlotFile) {
+		_ = file.Close()
+	}(file)
 
-	return pc, nil
+	header, err := ReadHeader(bufio.NewReaderSize(fileReader(file), headerReadSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := make([]byte, int(header.NumKeys)*KeyEntrySize)
+	if _, err := io.ReadFull(io.NewSectionReader(file, int64(header.Size()), int64(len(table))), table); err != nil {
+		return nil, nil, err
+	}
+	keyEntries, err := decodeKeyTable(header, table)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.ID == (PlotID{}) {
+		// Plots written before IDs get the ID they would have had
+		header.ID = plotRoot(keyEntries)
+	}
+
+	return &PlotInfo{
+		Header:     header,
+		KeyEntries: keyEntries,
+		Path:       filePath,
+		store:      store,
+	}, table, nil
 }
 
-func (pc *PlotCollection) LookUp(challengeHash []byte) (*Solution, error) {
-	var bestMatch []byte
+// decodeKeyTable decodes the key table of the plot described by h.
+func decodeKeyTable(h *Header, table []byte) ([]KeyEntry, error) {
+	keyEntries := make([]KeyEntry, h.NumKeys)
+	for i := range keyEntries {
+		if err := keyEntries[i].UnmarshalBinary(table[i*KeyEntrySize:]); err != nil {
+			return nil, err
+		}
+	}
+	return keyEntries, nil
+}
+
+// closest returns the plot and index of the key nearest to challengeHash,
+// and its distance, which is -1 when no keys are loaded.
+func (pc *PlotCollection) closest(challengeHash []byte) (*PlotInfo, int, int) {
+	var bestPlot *PlotInfo
+	var bestIndex int
 	var bestDistance = -1
-	var bestPlotPath string
-	var bestKeyEntry KeyEntry
 
-	for plotPath, plotInfo := range pc.Plots {
-		for _, keyEntry := range plotInfo.KeyEntries {
+	for _, plotInfo := range pc.Plots {
+		for i, keyEntry := range plotInfo.KeyEntries {
 			distance := HammingDistance(challengeHash, keyEntry.Hash[:])
 			if bestDistance == -1 || distance < bestDistance {
 				bestDistance = distance
-				bestMatch = keyEntry.Hash[:]
-				bestPlotPath = plotPath
-				bestKeyEntry = keyEntry
+				bestIndex = i
+				bestPlot = plotInfo
 			}
 		}
 	}
+	return bestPlot, bestIndex, bestDistance
+}
+
+// LookUpOptions controls LookUpWithOptions.
+type LookUpOptions struct {
+	// Target, when set, skips signing and returns no solution if the best
+	// distance found is above the target.
+	Target *Target
+}
 
+// LookUp finds the key closest to challengeHash and signs a solution with it,
+// proving the key is in its plot.
+func (pc *PlotCollection) LookUp(challengeHash []byte) (*Solution, error) {
+	return pc.LookUpWithOptions(challengeHash, LookUpOptions{})
+}
+
+// LookUpWithOptions is LookUp with an optional difficulty target.
+func (pc *PlotCollection) LookUpWithOptions(challengeHash []byte, opts LookUpOptions) (*Solution, error) {
+	bestPlot, bestIndex, bestDistance := pc.closest(challengeHash)
 	if bestDistance == -1 {
 		return nil, nil // No plots loaded
 	}
+	if opts.Target != nil && bestDistance > opts.Target.MaxDistance {
+		return nil, nil // Not good enough to be worth signing
+	}
+	bestKeyEntry := bestPlot.KeyEntries[bestIndex]
+	bestMatch := bestKeyEntry.Hash[:]
 
 	// Now retrieve the private key
-	file, err := os.Open(bestPlotPath)
+	bestHeader := bestPlot.Header
+	file, err := storeOrOS(bestPlot.store).Open(bestPlot.Path)
 	if err != nil {
 		return nil, err
 	}
-	defer func(file *os.File) {
+	defer func(file PlotFile) {
 		_ = file.Close()
 	}(file)
 
-	_, err = file.Seek(int64(bestKeyEntry.Offset), 0)
+	keyData := make([]byte, bestHeader.keyDataSize())
+	_, err = io.ReadFull(io.NewSectionReader(file, int64(bestKeyEntry.Offset), int64(len(keyData))), keyData)
 	if err != nil {
 		return nil, err
 	}
 
-	skBytes := make([]byte, mldsa87.PrivateKeySize)
-	_, err = file.Read(skBytes)
+	var sk Signer
+	if bestHeader.Format == FormatSeed {
+		// Compact plots only store the seed, so expand the winning key now
+		sk, err = bestHeader.Scheme.NewKeyFromSeed(keyData)
+	} else {
+		sk, err = bestHeader.Scheme.UnmarshalPrivateKey(keyData)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	sk := &mldsa87.PrivateKey{}
-	err = sk.UnmarshalBinary(skBytes)
+	solution, err := NewSolutionWithSigner(bestMatch, bestDistance, sk)
 	if err != nil {
 		return nil, err
 	}
-
-	return NewSolution(bestMatch, bestDistance, sk)
+	solution.bind(bestHeader)
+	solution.Proof = bestPlot.inclusionProof(bestIndex)
+	return solution, nil
 }
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/sha3"
+	"encoding/hex"
+	"fmt"
+)
+
+// PlotIDSize is the size of a plot ID.
+const PlotIDSize = 32
+
+// PlotID identifies a plot by its content: the root of a Merkle tree over the
+// hashes of its key table, in table order. Copies of a plot share an ID
+// wherever they are stored and whatever their files are called.
+//
+// The tree is the one of RFC 6962 with SHA3-256: leaves are hashed with a
+// 0x00 prefix and inner nodes with 0x01, and a level with an odd number of
+// nodes carries its last node up unchanged.
+type PlotID [PlotIDSize]byte
+
+func (id PlotID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id PlotID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *PlotID) UnmarshalText(text []byte) error {
+	if hex.DecodedLen(len(text)) != PlotIDSize {
+		return fmt.Errorf("plot ID must be %d hex characters", 2*PlotIDSize)
+	}
+	_, err := hex.Decode(id[:], text)
+	return err
+}
+
+// Domain separation prefixes, so a leaf can't be passed off as an inner node.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+func merkleLeaf(hash [32]byte) [32]byte {
+	var b [1 + 32]byte
+	b[0] = merkleLeafPrefix
+	copy(b[1:], hash[:])
+	return sha3.Sum256(b[:])
+}
+
+func merkleNode(left, right [32]byte) [32]byte {
+	var b [1 + 64]byte
+	b[0] = merkleNodePrefix
+	copy(b[1:], left[:])
+	copy(b[33:], right[:])
+	return sha3.Sum256(b[:])
+}
+
+// merkleBuilder computes the root of the tree over leaves added one at a
+// time, keeping only the roots of the complete subtrees seen so far.
+type merkleBuilder struct {
+	subtrees []merkleSubtree
+}
+
+type merkleSubtree struct {
+	leaves uint64
+	hash   [32]byte
+}
+
+func (b *merkleBuilder) add(hash [32]byte) {
+	t := merkleSubtree{leaves: 1, hash: merkleLeaf(hash)}
+	for n := len(b.subtrees); n > 0 && b.subtrees[n-1].leaves == t.leaves; n-- {
+		t = merkleSubtree{leaves: 2 * t.leaves, hash: merkleNode(b.subtrees[n-1].hash, t.hash)}
+		b.subtrees = b.subtrees[:n-1]
+	}
+	b.subtrees = append(b.subtrees, t)
+}
+
+// root returns the root of the tree, joining the subtrees right to left.
+func (b *merkleBuilder) root() PlotID {
+	if len(b.subtrees) == 0 {
+		return sha3.Sum256(nil)
+	}
+	hash := b.subtrees[len(b.subtrees)-1].hash
+	for i := len(b.subtrees) - 2; i >= 0; i-- {
+		hash = merkleNode(b.subtrees[i].hash, hash)
+	}
+	return hash
+}
+
+// plotRoot returns the ID of the plot with the key table entries.
+func plotRoot(entries []KeyEntry) PlotID {
+	var b merkleBuilder
+	for _, ke := range entries {
+		b.add(ke.Hash)
+	}
+	return b.root()
+}
+
+// merkleTree holds the levels of a plot's tree above its leaves, from the
+// lowest, so inclusion paths are built without rehashing the table. It is
+// about 32 bytes per key.
+type merkleTree struct {
+	levels [][][32]byte
+}
+
+func newMerkleTree(entries []KeyEntry) *merkleTree {
+	t := &merkleTree{}
+	level := make([][32]byte, len(entries))
+	for i, ke := range entries {
+		level[i] = merkleLeaf(ke.Hash)
+	}
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i+1 < len(level); i += 2 {
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t
+}
+
+// path returns the inclusion path of entry index: the sibling of its node on
+// each level that has one, from the leaves up.
+func (t *merkleTree) path(entries []KeyEntry, index int) [][32]byte {
+	var path [][32]byte
+	if sibling := index ^ 1; sibling < len(entries) {
+		path = append(path, merkleLeaf(entries[sibling].Hash))
+	}
+	for _, level := range t.levels {
+		index >>= 1
+		if sibling := index ^ 1; sibling < len(level) {
+			path = append(path, level[sibling])
+		}
+	}
+	return path
+}
+
+// verifyMerklePath reports whether path proves that hash is entry index of
+// the numKeys entries of the plot with ID root, following RFC 9162
+// section 2.1.3.2.
+func verifyMerklePath(root PlotID, hash [32]byte, index, numKeys uint32, path [][32]byte) bool {
+	if index >= numKeys {
+		return false
+	}
+	fn, sn := index, numKeys-1
+	r := merkleLeaf(hash)
+	for _, p := range path {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = merkleNode(p, r)
+			// A last node without a sibling moves up unchanged
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = merkleNode(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && r == root
+}
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/sha3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// rfc6962Root is the Merkle tree hash of RFC 6962 section 2.1, written the
+// way the RFC defines it.
+func rfc6962Root(hashes [][32]byte) [32]byte {
+	switch len(hashes) {
+	case 0:
+		return sha3.Sum256(nil)
+	case 1:
+		return merkleLeaf(hashes[0])
+	}
+	k := 1
+	for k*2 < len(hashes) {
+		k *= 2
+	}
+	return merkleNode(rfc6962Root(hashes[:k]), rfc6962Root(hashes[k:]))
+}
+
+func TestPlotRoot(t *testing.T) {
+	var entries []KeyEntry
+	var hashes [][32]byte
+	for n := range 70 {
+		if got, want := plotRoot(entries), rfc6962Root(hashes); got != PlotID(want) {
+			t.Errorf("Root of %d leaves is %s, want %x", n, got, want)
+		}
+		hash := sha3.Sum256([]byte{byte(n)})
+		entries = append(entries, KeyEntry{Hash: hash})
+		hashes = append(hashes, hash)
+	}
+}
+
+func TestPlotID(t *testing.T) {
+	dir := t.TempDir()
+	path, err := PlotWithOptions(dir, 1, PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	})
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	pc, err := LoadPlots([]string{dir}, false)
+	if err != nil || len(pc.Plots) != 1 {
+		t.Fatalf("Failed to load plot: %v", err)
+	}
+	var plot *PlotInfo
+	for _, p := range pc.Plots {
+		plot = p
+	}
+	if plot.ID != plotRoot(plot.KeyEntries) {
+		t.Errorf("Header ID %s is not the root of the key table", plot.ID)
+	}
+	if name := filepath.Base(path); name != "sp2-"+plot.ID.String()+".plot" {
+		t.Errorf("Plot file %s isn't named after its ID %s", name, plot.ID)
+	}
+
+	solution, err := pc.LookUp(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("Failed to look up: %v", err)
+	}
+	if r, err := solution.raw(); err != nil || PlotID(r.PlotID) != plot.ID {
+		t.Errorf("Solution names plot %q, want %s", solution.PlotID, plot.ID)
+	}
+
+	// A copy under another name is the same plot, as is one without an ID
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyDir := filepath.Join(dir, "copy")
+	if err := os.Mkdir(copyDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	clear(data[plot.Size()-PlotIDSize : plot.Size()])
+	if err := os.WriteFile(filepath.Join(copyDir, "sp2-copy.plot"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := LoadPlots([]string{copyDir}, false)
+	if err != nil || len(legacy.Plots) != 1 || legacy.Plots[plot.ID] == nil {
+		t.Errorf("Expected a plot without an ID to get its ID from the table")
+	}
+	both, err := LoadPlots([]string{dir}, false)
+	if err != nil || len(both.Plots) != 1 || both.Plots[plot.ID].Path != filepath.Join(copyDir, "sp2-copy.plot") {
+		t.Errorf("Expected only the first of two copies of a plot to be loaded")
+	}
+}
+
+func TestMerklePath(t *testing.T) {
+	for n := 1; n <= 70; n++ {
+		entries := make([]KeyEntry, n)
+		for i := range entries {
+			entries[i].Hash = sha3.Sum256([]byte{byte(i)})
+		}
+		root, tree := plotRoot(entries), newMerkleTree(entries)
+		for i, ke := range entries {
+			path := tree.path(entries, i)
+			if !verifyMerklePath(root, ke.Hash, uint32(i), uint32(n), path) {
+				t.Fatalf("Path of entry %d of %d doesn't verify", i, n)
+			}
+			if verifyMerklePath(root, ke.Hash, uint32(i^1), uint32(n), path) {
+				t.Fatalf("Path of entry %d of %d verifies for entry %d", i, n, i^1)
+			}
+			if n > 1 && verifyMerklePath(root, ke.Hash, uint32(i), uint32(n), path[:len(path)-1]) {
+				t.Fatalf("Truncated path of entry %d of %d verifies", i, n)
+			}
+		}
+	}
+}
+
+func TestInclusionProof(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := PlotWithOptions(dir, 1, PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	}); err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	pc, err := LoadPlots([]string{dir}, false)
+	if err != nil || len(pc.Plots) != 1 {
+		t.Fatalf("Failed to load plot: %v", err)
+	}
+	var id PlotID
+	for id = range pc.Plots {
+		break
+	}
+	solution, err := pc.LookUp(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("Failed to look up: %v", err)
+	}
+	if solution.Proof == nil {
+		t.Fatalf("Solution has no inclusion proof")
+	}
+
+	// The proof survives the canonical encodings
+	b, err := solution.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary Solution
+	if err := fromBinary.UnmarshalBinary(b); err != nil || !reflect.DeepEqual(fromBinary.Proof, solution.Proof) {
+		t.Errorf("Inclusion proof changed in the binary encoding: %v", err)
+	}
+	c, err := solution.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromCBOR Solution
+	if err := fromCBOR.UnmarshalCBOR(c); err != nil || !reflect.DeepEqual(fromCBOR.Proof, solution.Proof) {
+		t.Errorf("Inclusion proof changed in the CBOR encoding: %v", err)
+	}
+
+	opts := VerifyOptions{
+		HashPolicy:     PermissiveHashPolicy,
+		PlotRegistered: func(got PlotID) bool { return got == id },
+	}
+	if valid, err := solution.VerifyWithOptions(opts); !valid || err != nil {
+		t.Errorf("Solution of a registered plot is invalid: %v", err)
+	}
+	other := *solution
+	other.PlotID = encodeAscii85(make([]byte, PlotIDSize))
+	if valid, _ := other.VerifyWithOptions(VerifyOptions{HashPolicy: PermissiveHashPolicy}); valid {
+		t.Errorf("Expected a proof for another plot to be rejected")
+	}
+	other = *solution
+	other.Proof = nil
+	if _, err := other.VerifyWithOptions(opts); err == nil {
+		t.Errorf("Expected a solution without a proof to be rejected")
+	}
+	opts.PlotRegistered = func(PlotID) bool { return false }
+	if valid, _ := solution.VerifyWithOptions(opts); valid {
+		t.Errorf("Expected a solution of an unregistered plot to be rejected")
+	}
+}
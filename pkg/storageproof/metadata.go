@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"slices"
+	"time"
+)
+
+// Types of the metadata records in a plot header.
+const (
+	metaCreatedAt      uint16 = 1 // int64 Unix time in nanoseconds
+	metaHostname       uint16 = 2 // UTF-8
+	metaPlotterVersion uint16 = 3 // UTF-8
+	metaLabel          uint16 = 4 // uint16 key length, key, value
+)
+
+// metaRecordHeaderSize is the size of the type and length of a record.
+const metaRecordHeaderSize = 6
+
+// Metadata records how a plot was made. It is stored in the plot header as a
+// sequence of type-length-value records, which readers that don't know a
+// type skip. The signature scheme and hash parameters are header fields of
+// their own.
+type Metadata struct {
+	CreatedAt      time.Time         `json:"created_at,omitzero"`
+	Hostname       string            `json:"hostname,omitempty"`
+	PlotterVersion string            `json:"plotter_version,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	// Unknown holds the records of types this version doesn't know, so
+	// rewriting a header keeps them.
+	Unknown []MetadataRecord `json:"-"`
+}
+
+// MetadataRecord is a raw metadata record.
+type MetadataRecord struct {
+	Type  uint16
+	Value []byte
+}
+
+// DefaultMetadata returns the metadata of a plot created now on this host.
+func DefaultMetadata() Metadata {
+	hostname, _ := os.Hostname()
+	return Metadata{
+		CreatedAt:      time.Now().UTC(),
+		Hostname:       hostname,
+		PlotterVersion: "plotlib " + libVersion,
+	}
+}
+
+// isZero reports whether m has no records.
+func (m *Metadata) isZero() bool {
+	return m.CreatedAt.IsZero() && m.Hostname == "" && m.PlotterVersion == "" && len(m.Labels) == 0 && len(m.Unknown) == 0
+}
+
+// marshal encodes the records of m, labels in key order.
+func (m *Metadata) marshal() ([]byte, error) {
+	var b []byte
+	add := func(typ uint16, value []byte) {
+		b = binary.LittleEndian.AppendUint16(b, typ)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(value)))
+		b = append(b, value...)
+	}
+
+	if !m.CreatedAt.IsZero() {
+		add(metaCreatedAt, binary.LittleEndian.AppendUint64(nil, uint64(m.CreatedAt.UnixNano())))
+	}
+	if m.Hostname != "" {
+		add(metaHostname, []byte(m.Hostname))
+	}
+	if m.PlotterVersion != "" {
+		add(metaPlotterVersion, []byte(m.PlotterVersion))
+	}
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if len(k) > 0xffff {
+			return nil, errors.New("plot label key too long")
+		}
+		value := binary.LittleEndian.AppendUint16(nil, uint16(len(k)))
+		add(metaLabel, append(append(value, k...), m.Labels[k]...))
+	}
+	for _, r := range m.Unknown {
+		add(r.Type, r.Value)
+	}
+
+	if len(b) > maxHeaderSize {
+		return nil, errors.New("plot metadata too large")
+	}
+	return b, nil
+}
+
+// unmarshal decodes the records in data.
+func (m *Metadata) unmarshal(data []byte) error {
+	*m = Metadata{}
+	for len(data) > 0 {
+		if len(data) < metaRecordHeaderSize {
+			return errors.New("plot metadata record too short")
+		}
+		typ := binary.LittleEndian.Uint16(data[0:2])
+		n := binary.LittleEndian.Uint32(data[2:6])
+		if uint64(n) > uint64(len(data)-metaRecordHeaderSize) {
+			return errors.New("plot metadata record too short")
+		}
+		value := data[metaRecordHeaderSize : metaRecordHeaderSize+int(n)]
+		data = data[metaRecordHeaderSize+int(n):]
+
+		switch typ {
+		case metaCreatedAt:
+			if len(value) != 8 {
+				return errors.New("invalid plot creation time")
+			}
+			m.CreatedAt = time.Unix(0, int64(binary.LittleEndian.Uint64(value))).UTC()
+		case metaHostname:
+			m.Hostname = string(value)
+		case metaPlotterVersion:
+			m.PlotterVersion = string(value)
+		case metaLabel:
+			if len(value) < 2 || int(binary.LittleEndian.Uint16(value)) > len(value)-2 {
+				return errors.New("invalid plot label")
+			}
+			keyLen := int(binary.LittleEndian.Uint16(value))
+			if m.Labels == nil {
+				m.Labels = make(map[string]string)
+			}
+			m.Labels[string(value[2:2+keyLen])] = string(value[2+keyLen:])
+		default:
+			m.Unknown = append(m.Unknown, MetadataRecord{Type: typ, Value: append([]byte(nil), value...)})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MaxKValue is the largest kValue whose key count fits in a plot header.
+const MaxKValue = math.MaxUint32 / 1000
+
+// calibrationTime bounds how long PlotPlan spends timing key generation.
+const calibrationTime = time.Second
+
+var (
+	// ErrTooManyKeys is returned when kValue*1000 keys don't fit in a plot.
+	ErrTooManyKeys = errors.New("too many keys")
+	// ErrInsufficientSpace is returned when the destination can't hold a plot.
+	ErrInsufficientSpace = errors.New("insufficient disk space")
+)
+
+// Plan describes a plot before it is created.
+type Plan struct {
+	NumKeys  uint32 `json:"num_keys"`
+	FileSize uint64 `json:"file_size"`
+	// EstimatedTime extrapolates a short key generation run to the whole plot.
+	EstimatedTime time.Duration `json:"estimated_ns"`
+	// FreeSpace is the space available in the destination, or zero when it
+	// can't be determined on this platform.
+	FreeSpace uint64 `json:"free_space"`
+	// Fits reports whether the destination has room for the plot. It is true
+	// when the free space is unknown.
+	Fits bool `json:"fits"`
+}
+
+// PlotPlan returns the exact size of a plot of kValue thousand keys, an
+// estimate of how long it takes to create from a short calibration run, and
+// whether destDir has room for it. An empty destDir skips the space check.
+func PlotPlan(destDir string, kValue uint32, opts PlotOptions) (*Plan, error) {
+	h, err := newPlotHeader(kValue, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{NumKeys: h.NumKeys, FileSize: plotFileSize(h), Fits: true}
+	if destDir != "" {
+		free, err := storeFreeSpace(opts.Store, destDir)
+		switch {
+		case err == nil:
+			plan.FreeSpace = free
+			plan.Fits = free >= plan.FileSize
+		case !errors.Is(err, errors.ErrUnsupported):
+			return nil, err
+		}
+	}
+
+	// Generate keys until the calibration time is up, at least one
+	start := time.Now()
+	var n uint32
+	for n < h.NumKeys && (n == 0 || time.Since(start) < calibrationTime) {
+		if _, _, err := h.generateKey(nil); err != nil {
+			return nil, err
+		}
+		n++
+	}
+	plan.EstimatedTime = time.Since(start) / time.Duration(n) * time.Duration(h.NumKeys)
+	return plan, nil
+}
+
+// plotFileSize returns the size of the plot file described by h.
+func plotFileSize(h *Header) uint64 {
+	return uint64(h.Size()) + uint64(h.NumKeys)*uint64(KeyEntrySize+h.keyDataSize())
+}
+
+// checkFreeSpace returns ErrInsufficientSpace if dir of store can't hold size
+// bytes. Stores and platforms without a way to query free space are not
+// checked.
+func checkFreeSpace(store PlotStore, dir string, size uint64) error {
+	free, err := storeFreeSpace(store, dir)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if free < size {
+		return fmt.Errorf("%w: plot needs %d bytes, %s has %d", ErrInsufficientSpace, size, dir, free)
+	}
+	return nil
+}
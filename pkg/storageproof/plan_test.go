@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestPlotPlan(t *testing.T) {
+	opts := PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	}
+	dir := t.TempDir()
+
+	plan, err := PlotPlan(dir, 1, opts)
+	if err != nil {
+		t.Fatalf("Failed to plan plot: %v", err)
+	}
+	if plan.NumKeys != 1000 || plan.EstimatedTime <= 0 {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+
+	path, err := PlotWithOptions(dir, 1, opts)
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat plot: %v", err)
+	}
+	if uint64(info.Size()) != plan.FileSize {
+		t.Errorf("Planned %d bytes, plot is %d", plan.FileSize, info.Size())
+	}
+
+	if _, err := PlotPlan(dir, MaxKValue+1, opts); !errors.Is(err, ErrTooManyKeys) {
+		t.Errorf("Expected ErrTooManyKeys, got %v", err)
+	}
+	// Some 20 TB of ML-DSA-87 private keys
+	opts.Format, opts.Scheme = FormatPrivateKey, SchemeMLDSA87
+	if _, err := PlotWithOptions(dir, MaxKValue, opts); !errors.Is(err, ErrInsufficientSpace) {
+		t.Errorf("Expected ErrInsufficientSpace, got %v", err)
+	}
+}
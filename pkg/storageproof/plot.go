@@ -5,65 +5,114 @@ package storageproof
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"maps"
 	"time"
 
-	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/argon2"
 )
 
 const libVersion = "0.0.1"
 
-func Plot(destDir string, kValue uint32, verbose bool) error {
-	numKeys := kValue * 1000
+// PlotOptions controls how a plot file is laid out.
+type PlotOptions struct {
+	// Format selects whether full private keys or key seeds are stored.
+	Format KeyFormat
+	// Scheme selects the signature scheme of the plotted keys.
+	Scheme SchemeID
+	// HashParams selects how public keys are hashed. Nil uses DefaultHashParams.
+	HashParams *HashParams
+	// TmpDir, if set, is where the plot is created before it is moved to
+	// its destination, e.g. a fast SSD in front of the HDD it is farmed from.
+	TmpDir string
+	// FarmerKey binds the plot to its owner by mixing it into every key hash,
+	// optionally together with PoolKey. Leave empty for an unbound plot.
+	FarmerKey []byte
+	PoolKey   []byte
+	// Preallocate reserves the whole file before plotting, where the
+	// platform supports it, to avoid fragmentation.
+	Preallocate bool
+	// DirectIO bypasses the page cache when writing key data on Linux,
+	// falling back to buffered writes where it's unsupported.
+	DirectIO bool
+	// Metadata is recorded in the plot header. Nil uses DefaultMetadata.
+	Metadata *Metadata
+	// Labels are added to the labels of the metadata.
+	Labels map[string]string
+	// Rand is the source of key seeds, crypto/rand when nil. Set it only to
+	// make reproducible plots, such as test vectors.
+	Rand io.Reader
+	// Store holds the plot and temporary directories, OSStore when nil.
+	// Preallocate and DirectIO only apply to OSStore.
+	Store   PlotStore
+	Verbose bool
+}
 
-	// Generate a new UUID for the plot file
-	guid := uuid.New()
-	fileName := fmt.Sprintf("sp%d%s.plot", Version, guid.String())
-	filePath := fmt.Sprintf("%s/%s", destDir, fileName)
+// Plot creates a new plot file of kValue thousand keys in destDir, storing
+// full ML-DSA-87 private keys.
+func Plot(destDir string, kValue uint32, verbose bool) error {
+	_, err := PlotWithOptions(destDir, kValue, PlotOptions{Verbose: verbose})
+	return err
+}
 
-	// Create the plot file
-	file, err := os.Create(filePath)
+// PlotWithOptions creates a new plot file of kValue thousand keys in destDir
+// and returns its path. It refuses to start when destDir, or opts.TmpDir if
+// set, doesn't have room for the plot, see PlotPlan.
+func PlotWithOptions(destDir string, kValue uint32, opts PlotOptions) (string, error) {
+	h, err := newPlotHeader(kValue, opts)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
-
-	// Write a placeholder for the header
-	h := &Header{
-		Version: Version,
-		NumKeys: numKeys,
+	if err := checkFreeSpace(opts.Store, destDir, plotFileSize(h)); err != nil {
+		return "", err
+	}
+	if opts.TmpDir == "" {
+		return writePlot(destDir, h, opts)
 	}
-	copy(h.LibVersion[:], libVersion)
 
-	headerBytes, err := h.MarshalBinary()
+	partial, err := stagePlot(h, opts)
 	if err != nil {
-		return err
+		return "", err
 	}
+	return unstagePlot(opts.Store, partial, h, destDir)
+}
 
-	_, err = file.Write(headerBytes)
+// writePlot creates the plot described by h in destDir and returns its path.
+func writePlot(destDir string, h *Header, opts PlotOptions) (string, error) {
+	partial, err := writePartialPlot(destDir, h, opts)
 	if err != nil {
-		return err
+		return "", err
 	}
+	store := storeOrOS(opts.Store)
+	filePath := fmt.Sprintf("%s/%s", destDir, plotFileName(h.ID))
+	if err := store.Rename(partial, filePath); err != nil {
+		_ = store.Remove(partial)
+		return "", err
+	}
+	return filePath, nil
+}
+
+// writePartialPlot creates the plot described by h in dir under a temporary
+// name, which it returns, and sets the plot's ID in h.
+func writePartialPlot(dir string, h *Header, opts PlotOptions) (string, error) {
+	numKeys := h.NumKeys
 
-	// Write a placeholder for the key entries
-	keyEntries := make([]KeyEntry, numKeys)
-	keyEntriesBytes := make([]byte, 40*numKeys)
-	_, err = file.Write(keyEntriesBytes)
+	// Create the plot file under a temporary name until it is complete and
+	// its ID, which names it, is known
+	partial := fmt.Sprintf("%s/sp%d-%s.plot%s", dir, Version, uuid.New(), partialSuffix)
+	store := storeOrOS(opts.Store)
+	w, err := newPlotWriter(store, partial, h, opts)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	startTime := time.Now()
 
 	// Generate keys and write them to the file
 	for i := uint32(0); i < numKeys; i++ {
-		if verbose {
+		if opts.Verbose {
 			// Calculate ETA
 			elapsed := time.Since(startTime)
 			progress := float64(i+1) / float64(numKeys)
@@ -71,63 +120,118 @@ func Plot(destDir string, kValue uint32, verbose bool) error {
 			fmt.Printf("Plotting key %d of %d (ETA: %s)\r", i+1, numKeys, eta.Round(time.Second))
 		}
 
-		// Generate a new key pair
-		pk, sk, err := mldsa87.GenerateKey(rand.Reader)
+		keyData, hash, err := h.generateKey(opts.Rand)
 		if err != nil {
-			return err
+			w.abort()
+			return "", err
 		}
-
-		// Get the current offset
-		offset, err := file.Seek(0, io.SeekCurrent)
-		if err != nil {
-			return err
+		if err := w.add(keyData, hash); err != nil {
+			w.abort()
+			return "", err
 		}
+	}
 
-		// Write the private key to the file
-		skBytes, err := sk.MarshalBinary()
-		if err != nil {
-			return err
-		}
-		_, err = file.Write(skBytes)
-		if err != nil {
-			return err
-		}
+	if err := w.close(); err != nil {
+		w.abort()
+		return "", err
+	}
+	return partial, nil
+}
 
-		// Generate the public key hash
-		pkBytes, err := pk.MarshalBinary()
-		if err != nil {
-			return err
-		}
-		hash := argon2.IDKey(pkBytes, []byte("storageproof"), 1, 64*1024, 4, 32)
+// plotFileName returns the file name of the plot with the given ID.
+func plotFileName(id PlotID) string {
+	return fmt.Sprintf("sp%d-%s.plot", Version, id)
+}
 
-		// Update the key entry
-		keyEntries[i].Offset = uint64(offset)
-		copy(keyEntries[i].Hash[:], hash)
+// newPlotHeader validates the options and returns the header of a plot of
+// kValue thousand keys.
+func newPlotHeader(kValue uint32, opts PlotOptions) (*Header, error) {
+	if opts.Format != FormatPrivateKey && opts.Format != FormatSeed {
+		return nil, fmt.Errorf("unknown plot key format %d", opts.Format)
+	}
+	if !opts.Scheme.Valid() {
+		return nil, fmt.Errorf("unknown signature scheme %d", opts.Scheme)
+	}
+	hashParams := DefaultHashParams()
+	if opts.HashParams != nil {
+		hashParams = *opts.HashParams
+	}
+	if err := hashParams.Validate(); err != nil {
+		return nil, err
+	}
+	if len(opts.FarmerKey) == 0 && len(opts.PoolKey) != 0 {
+		return nil, errors.New("pool key requires a farmer key")
+	}
+	if kValue == 0 {
+		return nil, errors.New("kValue must be at least 1")
+	}
+	if kValue > MaxKValue {
+		return nil, fmt.Errorf("%w: kValue %d exceeds %d", ErrTooManyKeys, kValue, MaxKValue)
 	}
 
-	// Go back to the beginning of the file and write the final header and key entries
-	_, err = file.Seek(0, io.SeekStart)
-	if err != nil {
-		return err
+	metadata := DefaultMetadata()
+	if opts.Metadata != nil {
+		metadata = *opts.Metadata
+	}
+	if len(opts.Labels) != 0 {
+		labels := maps.Clone(metadata.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		maps.Copy(labels, opts.Labels)
+		metadata.Labels = labels
+	}
+	if _, err := metadata.marshal(); err != nil {
+		return nil, err
 	}
 
-	_, err = file.Write(headerBytes)
+	h := &Header{
+		Version:    Version,
+		NumKeys:    kValue * 1000,
+		Format:     opts.Format,
+		Scheme:     opts.Scheme,
+		HashParams: hashParams,
+		FarmerKey:  opts.FarmerKey,
+		PoolKey:    opts.PoolKey,
+		Metadata:   metadata,
+	}
+	copy(h.LibVersion[:], libVersion)
+	return h, nil
+}
+
+// generateKey generates a key pair from a fresh seed read from rng, or
+// crypto/rand when nil, and returns the data stored for it in the plot, the
+// private key or its seed, along with the hash of its public key.
+func (h *Header) generateKey(rng io.Reader) ([]byte, [32]byte, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	seed := make([]byte, h.Scheme.SeedSize())
+	if _, err := io.ReadFull(rng, seed); err != nil {
+		return nil, [32]byte{}, err
+	}
+	sk, err := h.Scheme.NewKeyFromSeed(seed)
 	if err != nil {
-		return err
+		return nil, [32]byte{}, err
 	}
 
-	for _, ke := range keyEntries {
-		keBytes, err := ke.MarshalBinary()
+	keyData := seed
+	if h.Format != FormatSeed {
+		keyData, err = sk.MarshalBinary()
 		if err != nil {
-			return err
-		}
-		_, err = file.Write(keBytes)
-		if err != nil {
-			return err
+			return nil, [32]byte{}, err
 		}
 	}
 
-	return nil
+	pkBytes, err := sk.Public().MarshalBinary()
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	hash, err := h.hashKey(pkBytes)
+	if err != nil {
+		return nil, [32]byte{}, err
+	}
+	return keyData, hash, nil
 }
 
 // HammingDistance calculates the hamming distance between two byte slices
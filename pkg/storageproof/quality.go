@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import "math"
+
+// hashBits is the number of bits compared by HammingDistance for a plot hash.
+const hashBits = 32 * 8
+
+// Target is the largest distance a solution may have to be accepted.
+type Target struct {
+	MaxDistance int `json:"max_distance"`
+}
+
+// TargetForDifficulty returns the target a single random key meets with a
+// probability of at most 2^-difficulty.
+func TargetForDifficulty(difficulty float64) Target {
+	t := Target{MaxDistance: -1}
+	for d := 0; d <= hashBits; d++ {
+		if -logDistanceProbability(d)/math.Ln2 < difficulty {
+			break
+		}
+		t.MaxDistance = d
+	}
+	return t
+}
+
+// MeetsTarget reports whether the solution is close enough to challenge. The
+// distance is recomputed from the solution's hash, as Distance is only what
+// the solver claims; the signature is left to Verify.
+func (s *Solution) MeetsTarget(challenge []byte, t Target) bool {
+	hash, err := decodeAscii85(s.Hash, 32)
+	if err != nil {
+		return false
+	}
+	distance := HammingDistance(challenge, hash)
+	return distance >= 0 && distance <= t.MaxDistance
+}
+
+// Quality scores a distance found by a farm of totalKeys keys as the number
+// of bits of luck it took: -log2 of the probability that a farm of that size
+// finds a key at least this close to a random challenge. Larger is better,
+// and a farm of totalKeys keys scores about 1 on a typical challenge.
+func Quality(distance int, totalKeys uint64) float64 {
+	if distance < 0 || distance > hashBits {
+		return 0
+	}
+	if totalKeys == 0 {
+		totalKeys = 1
+	}
+
+	// 1 - (1-p)^n, computed without losing tiny probabilities to rounding
+	logP := logDistanceProbability(distance)
+	var pFarm float64
+	if logP == 0 {
+		pFarm = 1
+	} else {
+		pFarm = -math.Expm1(float64(totalKeys) * math.Log1p(-math.Exp(logP)))
+	}
+	if pFarm <= 0 {
+		// Underflow: the union bound is exact enough at this scale
+		return -(logP + math.Log(float64(totalKeys))) / math.Ln2
+	}
+	return -math.Log2(pFarm)
+}
+
+// ExpectedBestDistance returns the mean distance of the closest of
+// totalKeys random keys to a random challenge, the distance a farm of that
+// size typically finds.
+func ExpectedBestDistance(totalKeys uint64) float64 {
+	if totalKeys == 0 {
+		return 0
+	}
+	// The sum over d of the probability that no key is within d bits
+	var expected float64
+	for d := 0; d < hashBits; d++ {
+		expected += math.Exp(float64(totalKeys) * math.Log1p(-math.Exp(logDistanceProbability(d))))
+	}
+	return expected
+}
+
+// Quality scores the solution for a farm of totalKeys keys, see Quality.
+func (s *Solution) Quality(totalKeys uint64) float64 {
+	return Quality(s.Distance, totalKeys)
+}
+
+// logDistanceProbability returns the natural log of the probability that a
+// random hash is within distance bits of a challenge.
+func logDistanceProbability(distance int) float64 {
+	if distance >= hashBits {
+		return 0
+	}
+	// log-sum-exp over the binomial coefficients, divided by 2^hashBits
+	terms := make([]float64, distance+1)
+	maxTerm := math.Inf(-1)
+	for i := range terms {
+		terms[i] = logChoose(hashBits, i)
+		maxTerm = math.Max(maxTerm, terms[i])
+	}
+	var sum float64
+	for _, term := range terms {
+		sum += math.Exp(term - maxTerm)
+	}
+	return maxTerm + math.Log(sum) - hashBits*math.Ln2
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
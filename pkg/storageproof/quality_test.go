@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQualityAndTarget(t *testing.T) {
+	// A single key matches a random challenge exactly with probability 2^-256
+	if q := Quality(0, 1); math.Abs(q-256) > 1e-6 {
+		t.Errorf("Expected quality 256 for an exact match, got %f", q)
+	}
+	// Every key is within 256 bits of every challenge
+	if q := Quality(hashBits, 1); q != 0 {
+		t.Errorf("Expected quality 0 for the worst distance, got %f", q)
+	}
+	// A bigger farm is less surprised by the same distance
+	if Quality(90, 1000) <= Quality(90, 1000000) {
+		t.Errorf("Expected quality to fall as farm size grows")
+	}
+
+	target := TargetForDifficulty(20)
+	if target.MaxDistance < 0 || target.MaxDistance >= 128 {
+		t.Fatalf("Unexpected target for difficulty 20: %d", target.MaxDistance)
+	}
+	if Quality(target.MaxDistance, 1) < 20 || Quality(target.MaxDistance+1, 1) >= 20 {
+		t.Errorf("Target %d is not the loosest distance with difficulty 20", target.MaxDistance)
+	}
+
+	// A challenge target.MaxDistance bits away from the solution's hash
+	hash := make([]byte, 32)
+	challenge := make([]byte, 32)
+	for i := range target.MaxDistance {
+		challenge[i/8] |= 1 << (i % 8)
+	}
+	solution := &Solution{Hash: encodeAscii85(hash), Distance: target.MaxDistance}
+	if !solution.MeetsTarget(challenge, target) {
+		t.Errorf("Expected solution at the target distance to meet it")
+	}
+	challenge[31] |= 1
+	if solution.MeetsTarget(challenge, target) {
+		t.Errorf("Expected solution above the target distance to miss it")
+	}
+
+	// Distance is what the solver claims, not what is checked
+	solution.Distance = 0
+	if solution.MeetsTarget(challenge, target) {
+		t.Errorf("Expected a solution claiming a smaller distance than its own to miss the target")
+	}
+	if solution.MeetsTarget(challenge[:16], target) {
+		t.Errorf("Expected a challenge of the wrong length to miss the target")
+	}
+}
+
+func TestExpectedBestDistance(t *testing.T) {
+	// A single key is on average half the bits away
+	if d := ExpectedBestDistance(1); math.Abs(d-hashBits/2) > 1e-6 {
+		t.Errorf("Expected distance %d for one key, got %f", hashBits/2, d)
+	}
+	prev := ExpectedBestDistance(1)
+	for _, keys := range []uint64{1000, 1000000, 1 << 40} {
+		d := ExpectedBestDistance(keys)
+		if d >= prev {
+			t.Errorf("Expected distance to fall as the farm grows, got %f for %d keys", d, keys)
+		}
+		prev = d
+	}
+}
@@ -0,0 +1,376 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JobStatus is the state of a plot job. Queued and running jobs are
+// incomplete.
+type JobStatus string
+
+const (
+	JobQueued   JobStatus = "queued"
+	JobRunning  JobStatus = "running"
+	JobDone     JobStatus = "done"
+	JobFailed   JobStatus = "failed"
+	JobCanceled JobStatus = "canceled"
+)
+
+const (
+	// RunnerHeartbeat is how often a runner should call PlotQueue.Heartbeat,
+	// and runnerTimeout how long after its last heartbeat it's presumed dead.
+	RunnerHeartbeat = 10 * time.Second
+	runnerTimeout   = 3 * RunnerHeartbeat
+	// lockTimeout bounds waiting for the queue lock, and locks older than
+	// staleLock were left behind by a process that died holding them.
+	lockTimeout = 10 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+// PlotJob is a plot waiting in, or run from, a PlotQueue.
+type PlotJob struct {
+	ID         int        `json:"id"`
+	KValue     uint32     `json:"k_value"`
+	DestDir    string     `json:"dest_dir"`
+	TmpDir     string     `json:"tmp_dir,omitempty"`
+	Format     KeyFormat  `json:"format"`
+	Scheme     SchemeID   `json:"scheme"`
+	HashParams HashParams `json:"hash_params"`
+	FarmerKey  string     `json:"farmer_key,omitempty"` // hex
+	PoolKey    string     `json:"pool_key,omitempty"`   // hex
+	// Preallocate and DirectIO are the plot writer options.
+	Preallocate bool `json:"preallocate,omitempty"`
+	DirectIO    bool `json:"direct_io,omitempty"`
+	// Labels are recorded in the plot metadata.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	Status   JobStatus     `json:"status"`
+	Attempts int           `json:"attempts,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Added    time.Time     `json:"added"`
+	Started  time.Time     `json:"started,omitzero"`
+	Finished time.Time     `json:"finished,omitzero"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+// StagingDir is where the job's plot is created. Each job gets its own
+// directory so whatever an interrupted attempt left behind can be removed.
+func (j *PlotJob) StagingDir() string {
+	base := j.TmpDir
+	if base == "" {
+		base = j.DestDir
+	}
+	return filepath.Join(base, fmt.Sprintf(".plotlib-job-%d", j.ID))
+}
+
+// PlotOptions returns the options the job plots with.
+func (j *PlotJob) PlotOptions() (PlotOptions, error) {
+	farmerKey, err := hex.DecodeString(j.FarmerKey)
+	if err != nil {
+		return PlotOptions{}, fmt.Errorf("invalid farmer key: %w", err)
+	}
+	poolKey, err := hex.DecodeString(j.PoolKey)
+	if err != nil {
+		return PlotOptions{}, fmt.Errorf("invalid pool key: %w", err)
+	}
+	hashParams := j.HashParams
+	return PlotOptions{
+		Format:      j.Format,
+		Scheme:      j.Scheme,
+		HashParams:  &hashParams,
+		TmpDir:      j.StagingDir(),
+		FarmerKey:   farmerKey,
+		PoolKey:     poolKey,
+		Preallocate: j.Preallocate,
+		DirectIO:    j.DirectIO,
+		Labels:      j.Labels,
+	}, nil
+}
+
+// PlotQueue is a queue of plot jobs kept in a JSON state file, so jobs
+// outlive the process that runs them. Every change is made holding a lock
+// file next to the state file, which is replaced atomically, so several
+// processes can share a queue. Only one of them runs it at a time.
+type PlotQueue struct {
+	path string
+
+	lockTimeout   time.Duration
+	staleLock     time.Duration
+	runnerTimeout time.Duration
+}
+
+// NewPlotQueue returns the queue kept in the state file path. A missing file
+// is an empty queue.
+func NewPlotQueue(path string) *PlotQueue {
+	return &PlotQueue{
+		path:          path,
+		lockTimeout:   lockTimeout,
+		staleLock:     staleLock,
+		runnerTimeout: runnerTimeout,
+	}
+}
+
+// queueState is the content of the queue file.
+type queueState struct {
+	NextID int          `json:"next_id"`
+	Runner *queueRunner `json:"runner,omitempty"`
+	Jobs   []*PlotJob   `json:"jobs"`
+}
+
+// queueRunner identifies the process running the queue.
+type queueRunner struct {
+	PID       int       `json:"pid"`
+	Heartbeat time.Time `json:"heartbeat"`
+}
+
+func (s *queueState) job(id int) *PlotJob {
+	for _, job := range s.Jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// Jobs returns every job in the queue, in the order they were added.
+func (q *PlotQueue) Jobs() ([]*PlotJob, error) {
+	s, err := q.read()
+	if err != nil {
+		return nil, err
+	}
+	return s.Jobs, nil
+}
+
+// Add queues count copies of job, assigning their IDs, and returns them.
+func (q *PlotQueue) Add(job PlotJob, count int) ([]*PlotJob, error) {
+	var added []*PlotJob
+	err := q.update(func(s *queueState) error {
+		for range max(count, 1) {
+			j := job
+			j.ID = s.NextID
+			j.Status = JobQueued
+			j.Added = time.Now()
+			s.NextID++
+			s.Jobs = append(s.Jobs, &j)
+			added = append(added, &j)
+		}
+		return nil
+	})
+	return added, err
+}
+
+// Cancel cancels queued or running jobs by ID and returns them. A running
+// job can't be interrupted, so RunJob removes its plot once it finishes.
+// Nothing is canceled if any of the jobs can't be.
+func (q *PlotQueue) Cancel(ids ...int) ([]*PlotJob, error) {
+	var canceled []*PlotJob
+	err := q.update(func(s *queueState) error {
+		for _, id := range ids {
+			job := s.job(id)
+			switch {
+			case job == nil:
+				return fmt.Errorf("no job %d", id)
+			case job.Status != JobQueued && job.Status != JobRunning:
+				return fmt.Errorf("job %d is already %s", id, job.Status)
+			}
+			job.Status = JobCanceled
+			canceled = append(canceled, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return canceled, nil
+}
+
+// Acquire makes the process pid the queue's runner, unless another runner
+// has sent a heartbeat recently. Jobs left running by a previous runner are
+// queued again, their staging directories removed, and returned.
+func (q *PlotQueue) Acquire(pid int) ([]*PlotJob, error) {
+	var interrupted []*PlotJob
+	err := q.update(func(s *queueState) error {
+		if s.Runner != nil && s.Runner.PID != pid && time.Since(s.Runner.Heartbeat) < q.runnerTimeout {
+			return fmt.Errorf("queue is already being run by process %d", s.Runner.PID)
+		}
+		s.Runner = &queueRunner{PID: pid, Heartbeat: time.Now()}
+		for _, job := range s.Jobs {
+			if job.Status == JobRunning {
+				job.Status = JobQueued
+				interrupted = append(interrupted, job)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range interrupted {
+		_ = os.RemoveAll(job.StagingDir())
+	}
+	return interrupted, nil
+}
+
+// Heartbeat marks the queue as still being run by pid, which should happen
+// every RunnerHeartbeat.
+func (q *PlotQueue) Heartbeat(pid int) error {
+	return q.update(func(s *queueState) error {
+		s.Runner = &queueRunner{PID: pid, Heartbeat: time.Now()}
+		return nil
+	})
+}
+
+// Release gives up running the queue, so the next runner needn't wait for
+// the heartbeat to expire.
+func (q *PlotQueue) Release() error {
+	return q.update(func(s *queueState) error {
+		s.Runner = nil
+		return nil
+	})
+}
+
+// Claim marks the next queued job as running and returns it, or nil when
+// there is none.
+func (q *PlotQueue) Claim() (*PlotJob, error) {
+	var claimed *PlotJob
+	err := q.update(func(s *queueState) error {
+		for _, job := range s.Jobs {
+			if job.Status == JobQueued {
+				job.Status = JobRunning
+				job.Attempts++
+				job.Started = time.Now()
+				job.Finished, job.Duration, job.Path, job.Error = time.Time{}, 0, "", ""
+				claimed = job
+				return nil
+			}
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+// RunJob plots a claimed job and records its outcome, returning the job as
+// recorded. The plot of a job canceled while it ran is removed.
+func (q *PlotQueue) RunJob(job *PlotJob, verbose bool) (*PlotJob, error) {
+	opts, plotErr := job.PlotOptions()
+	var path string
+	if plotErr == nil {
+		opts.Verbose = verbose
+		if plotErr = os.MkdirAll(opts.TmpDir, 0o755); plotErr == nil {
+			path, plotErr = PlotWithOptions(job.DestDir, job.KValue, opts)
+		}
+	}
+	_ = os.RemoveAll(job.StagingDir())
+
+	var recorded PlotJob
+	err := q.update(func(s *queueState) error {
+		current := s.job(job.ID)
+		if current == nil {
+			return fmt.Errorf("job %d was removed from the queue", job.ID)
+		}
+		current.Finished = time.Now()
+		current.Duration = current.Finished.Sub(current.Started)
+		switch {
+		case current.Status == JobCanceled:
+			if path != "" {
+				_ = os.Remove(path)
+			}
+			current.Error = "canceled while running"
+		case plotErr != nil:
+			current.Status, current.Error = JobFailed, plotErr.Error()
+		default:
+			current.Status, current.Path = JobDone, path
+		}
+		recorded = *current
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &recorded, nil
+}
+
+// read reads the queue file.
+func (q *PlotQueue) read() (*queueState, error) {
+	s := &queueState{NextID: 1}
+	data, err := os.ReadFile(q.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("error reading queue %s: %w", q.path, err)
+	}
+	return s, nil
+}
+
+// update applies fn to the queue while holding its lock, saving the result
+// unless fn fails. The file is replaced atomically so readers never see a
+// partial write.
+func (q *PlotQueue) update(fn func(s *queueState) error) error {
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s, err := q.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
+
+// lock takes the lock file next to the queue file, breaking locks left
+// behind by dead processes.
+func (q *PlotQueue) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+		return nil, err
+	}
+	lock := q.path + ".lock"
+	deadline := time.Now().Add(q.lockTimeout)
+	for {
+		file, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, _ = fmt.Fprintf(file, "%d\n", os.Getpid())
+			_ = file.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > q.staleLock {
+			_ = os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for queue lock %s", lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func queueTestJob(destDir string) PlotJob {
+	return PlotJob{
+		KValue:     1,
+		DestDir:    destDir,
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	}
+}
+
+func TestPlotQueue(t *testing.T) {
+	dir, destDir := t.TempDir(), t.TempDir()
+	q := NewPlotQueue(filepath.Join(dir, "queue.json"))
+
+	added, err := q.Add(queueTestJob(destDir), 2)
+	if err != nil {
+		t.Fatalf("Failed to add jobs: %v", err)
+	}
+	if len(added) != 2 || added[0].ID != 1 || added[1].ID != 2 || added[0].Status != JobQueued {
+		t.Fatalf("Unexpected jobs added: %+v", added)
+	}
+	if _, err := q.Acquire(1); err != nil {
+		t.Fatalf("Failed to acquire queue: %v", err)
+	}
+
+	// Queued jobs are claimed in order and run to completion
+	job, err := q.Claim()
+	if err != nil || job == nil || job.ID != 1 || job.Status != JobRunning || job.Attempts != 1 {
+		t.Fatalf("Expected to claim job 1, got %+v: %v", job, err)
+	}
+	done, err := q.RunJob(job, false)
+	if err != nil {
+		t.Fatalf("Failed to run job: %v", err)
+	}
+	if done.Status != JobDone || done.Path == "" || done.Error != "" {
+		t.Fatalf("Unexpected job outcome %+v", done)
+	}
+	if pc, err := LoadPlots([]string{destDir}, false); err != nil || len(pc.Plots) != 1 {
+		t.Errorf("Expected the job's plot in %s: %v", destDir, err)
+	}
+	if _, err := os.Stat(job.StagingDir()); !os.IsNotExist(err) {
+		t.Errorf("Expected the staging directory to be removed: %v", err)
+	}
+
+	// Cancelling a running job removes its plot once it finishes
+	job, err = q.Claim()
+	if err != nil || job == nil || job.ID != 2 {
+		t.Fatalf("Expected to claim job 2, got %+v: %v", job, err)
+	}
+	if canceled, err := q.Cancel(2); err != nil || len(canceled) != 1 {
+		t.Fatalf("Failed to cancel job: %v", err)
+	}
+	canceled, err := q.RunJob(job, false)
+	if err != nil {
+		t.Fatalf("Failed to run job: %v", err)
+	}
+	if canceled.Status != JobCanceled || canceled.Path != "" || canceled.Error == "" {
+		t.Errorf("Unexpected outcome of a canceled job %+v", canceled)
+	}
+	if entries, _ := os.ReadDir(destDir); len(entries) != 1 {
+		t.Errorf("Expected only the first job's plot, got %d files", len(entries))
+	}
+	if _, err := q.Cancel(1); err == nil {
+		t.Errorf("Expected a finished job not to be canceled")
+	}
+	if _, err := q.Cancel(3); err == nil {
+		t.Errorf("Expected an unknown job not to be canceled")
+	}
+
+	if job, err := q.Claim(); err != nil || job != nil {
+		t.Errorf("Expected no job left to claim, got %+v: %v", job, err)
+	}
+	if err := q.Release(); err != nil {
+		t.Fatalf("Failed to release queue: %v", err)
+	}
+	jobs, err := NewPlotQueue(q.path).Jobs()
+	if err != nil || len(jobs) != 2 || jobs[0].Status != JobDone || jobs[1].Status != JobCanceled {
+		t.Errorf("Unexpected jobs read back: %v", err)
+	}
+}
+
+func TestPlotQueueRestart(t *testing.T) {
+	dir, destDir := t.TempDir(), t.TempDir()
+	q := NewPlotQueue(filepath.Join(dir, "queue.json"))
+	if _, err := q.Add(queueTestJob(destDir), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Acquire(1); err != nil {
+		t.Fatal(err)
+	}
+	job, err := q.Claim()
+	if err != nil || job == nil {
+		t.Fatalf("Failed to claim job: %v", err)
+	}
+	// What the runner had written when it died
+	if err := os.MkdirAll(job.StagingDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another runner waits for the heartbeat to expire
+	if _, err := q.Acquire(2); err == nil || !strings.Contains(err.Error(), "process 1") {
+		t.Errorf("Expected the queue to be taken by process 1, got %v", err)
+	}
+	q.runnerTimeout = 0
+	interrupted, err := q.Acquire(2)
+	if err != nil {
+		t.Fatalf("Failed to acquire an abandoned queue: %v", err)
+	}
+	if len(interrupted) != 1 || interrupted[0].ID != job.ID || interrupted[0].Status != JobQueued {
+		t.Fatalf("Expected job %d to be restarted, got %+v", job.ID, interrupted)
+	}
+	if _, err := os.Stat(job.StagingDir()); !os.IsNotExist(err) {
+		t.Errorf("Expected the interrupted job's staging directory to be removed: %v", err)
+	}
+
+	job, err = q.Claim()
+	if err != nil || job == nil || job.Attempts != 2 {
+		t.Fatalf("Expected to claim the job a second time, got %+v: %v", job, err)
+	}
+	if done, err := q.RunJob(job, false); err != nil || done.Status != JobDone {
+		t.Errorf("Expected the restarted job to finish: %v", err)
+	}
+}
+
+func TestPlotQueueLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q := NewPlotQueue(path)
+
+	// Concurrent updates are serialised, so none is lost
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.Add(queueTestJob(t.TempDir()), 1); err != nil {
+				t.Errorf("Failed to add job: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	jobs, err := q.Jobs()
+	if err != nil || len(jobs) != 8 {
+		t.Fatalf("Expected 8 jobs, got %d: %v", len(jobs), err)
+	}
+	for i, job := range jobs {
+		if job.ID != i+1 {
+			t.Errorf("Job %d has ID %d", i+1, job.ID)
+		}
+	}
+
+	// A held lock makes others wait, then give up
+	unlock, err := q.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := NewPlotQueue(path)
+	other.lockTimeout = 100 * time.Millisecond
+	if _, err := other.Add(queueTestJob(t.TempDir()), 1); err == nil {
+		t.Errorf("Expected adding to time out while the queue is locked")
+	}
+	unlock()
+	if _, err := other.Add(queueTestJob(t.TempDir()), 1); err != nil {
+		t.Errorf("Failed to add job once the queue is unlocked: %v", err)
+	}
+
+	// A lock left behind by a dead process is broken once it's stale
+	lock := path + ".lock"
+	if err := os.WriteFile(lock, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Add(queueTestJob(t.TempDir()), 1); err == nil {
+		t.Errorf("Expected a fresh lock to be respected")
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Add(queueTestJob(t.TempDir()), 1); err != nil {
+		t.Errorf("Expected a stale lock to be broken: %v", err)
+	}
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be released: %v", err)
+	}
+	if jobs, err := q.Jobs(); err != nil || len(jobs) != 10 {
+		t.Errorf("Expected 10 jobs, got %d: %v", len(jobs), err)
+	}
+}
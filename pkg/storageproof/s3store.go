@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptySHA256 is the hex SHA-256 of an empty request body.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Store serves plots read-only from a bucket of an S3-compatible object
+// store. Names are object keys, with directories as slash-separated key
+// prefixes. Loading a plot fetches its header and key table, and a lookup
+// fetches only the winning key, each with a ranged GET.
+//
+// Requests use path-style URLs and are signed with AWS Signature Version 4,
+// or sent anonymously when AccessKey is empty.
+type S3Store struct {
+	// Endpoint is the base URL of the service, e.g. https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+	Bucket   string
+	// Region is the signing region, us-east-1 when empty.
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+func (s *S3Store) Open(name string) (PlotFile, error) {
+	info, err := s.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Object{store: s, key: s3Key(name), size: info.Size()}, nil
+}
+
+func (s *S3Store) Create(name string) (PlotFileWriter, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: errors.ErrUnsupported}
+}
+
+func (s *S3Store) List(dir string) ([]string, error) {
+	prefix := s3Key(dir)
+	if prefix == "." {
+		prefix = ""
+	}
+
+	var names []string
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := s.do(http.MethodGet, "", query, "")
+		if err != nil {
+			return nil, &fs.PathError{Op: "list", Path: dir, Err: err}
+		}
+		var result struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, &fs.PathError{Op: "list", Path: dir, Err: err}
+		}
+
+		for _, c := range result.Contents {
+			if prefix == "" || c.Key == prefix || strings.HasPrefix(c.Key, prefix+"/") {
+				names = append(names, c.Key)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (s *S3Store) Stat(name string) (fs.FileInfo, error) {
+	key := s3Key(name)
+	resp, err := s.do(http.MethodHead, key, nil, "")
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	_ = resp.Body.Close()
+
+	size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: errors.New("missing object size")}
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return memFileInfo{name: path.Base(key), size: size, modTime: modTime}, nil
+}
+
+func (s *S3Store) Rename(oldName, newName string) error {
+	return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: errors.ErrUnsupported}
+}
+
+func (s *S3Store) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: errors.ErrUnsupported}
+}
+
+// s3Key turns a store name into an object key.
+func s3Key(name string) string {
+	return strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, `\`, "/")), "/")
+}
+
+// do sends a signed request for key, or for the bucket when key is empty,
+// and returns the response if it succeeded.
+func (s *S3Store) do(method, key string, query url.Values, byteRange string) (*http.Response, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.Path += "/" + s.Bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	if s.AccessKey != "" {
+		s.sign(req, time.Now())
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(resp.Body)
+		return nil, s3Error(resp)
+	}
+	return resp, nil
+}
+
+// s3Error returns the error described by a failed response.
+func s3Error(resp *http.Response) error {
+	var e struct {
+		Code    string
+		Message string
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&e)
+	if e.Code == "" {
+		e.Code = resp.Status
+	}
+
+	err := fmt.Errorf("s3: %s", e.Code)
+	if e.Message != "" {
+		err = fmt.Errorf("s3: %s: %s", e.Code, e.Message)
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w (%w)", fs.ErrNotExist, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w (%w)", fs.ErrPermission, err)
+	}
+	return err
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req.
+func (s *S3Store) sign(req *http.Request, now time.Time) {
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Query values are escaped like path segments, with spaces as %20
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptySHA256,
+	}, "\n")
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := []byte("AWS4" + s.SecretKey)
+	for _, part := range []string{amzDate[:8], region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and slashes
+// unless escapeSlash is set, as Signature Version 4 requires.
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Object is an object opened for reading with ranged GETs.
+type s3Object struct {
+	store *S3Store
+	key   string
+	size  int64
+}
+
+func (o *s3Object) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= o.size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), o.size)
+	if end == off {
+		return 0, nil
+	}
+
+	resp, err := o.store.do(http.MethodGet, o.key, nil, fmt.Sprintf("bytes=%d-%d", off, end-1))
+	if err != nil {
+		return 0, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent {
+		// The server ignored the range and sent the whole object
+		if _, err := io.CopyN(io.Discard, resp.Body, off); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := io.ReadFull(resp.Body, p[:end-off])
+	if err != nil {
+		return n, err
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (o *s3Object) Close() error {
+	return nil
+}
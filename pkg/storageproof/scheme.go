@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/ed25519"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+// SchemeID identifies the signature scheme used by a plot and its solutions.
+// It is recorded in the plot header and in every Solution.
+type SchemeID uint8
+
+const (
+	// SchemeMLDSA87 is ML-DSA-87. It is the zero value so that plots and
+	// solutions created before scheme identifiers existed keep working.
+	SchemeMLDSA87 SchemeID = 0
+	SchemeMLDSA65 SchemeID = 1
+	SchemeMLDSA44 SchemeID = 2
+	// SchemeEd25519 is classic Ed25519, intended for fast test networks.
+	SchemeEd25519 SchemeID = 3
+)
+
+var schemes = map[SchemeID]struct {
+	name   string
+	scheme sign.Scheme
+}{
+	SchemeMLDSA87: {"ml-dsa-87", mldsa87.Scheme()},
+	SchemeMLDSA65: {"ml-dsa-65", mldsa65.Scheme()},
+	SchemeMLDSA44: {"ml-dsa-44", mldsa44.Scheme()},
+	SchemeEd25519: {"ed25519", ed25519.Scheme()},
+}
+
+// Signer is a plot private key that can sign challenge hashes.
+type Signer interface {
+	Scheme() SchemeID
+	Sign(msg []byte) ([]byte, error)
+	Public() Verifier
+	MarshalBinary() ([]byte, error)
+}
+
+// Verifier is a public key that checks signatures made by its Signer.
+type Verifier interface {
+	Scheme() SchemeID
+	Verify(msg, sig []byte) bool
+	MarshalBinary() ([]byte, error)
+}
+
+// SchemeByName returns the scheme with the given name, e.g. "ml-dsa-65".
+func SchemeByName(name string) (SchemeID, error) {
+	for id, s := range schemes {
+		if strings.EqualFold(s.name, name) {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signature scheme %q", name)
+}
+
+// Valid reports whether id is a known signature scheme.
+func (id SchemeID) Valid() bool {
+	_, ok := schemes[id]
+	return ok
+}
+
+func (id SchemeID) String() string {
+	if s, ok := schemes[id]; ok {
+		return s.name
+	}
+	return fmt.Sprintf("scheme(%d)", uint8(id))
+}
+
+func (id SchemeID) MarshalText() ([]byte, error) {
+	if !id.Valid() {
+		return nil, fmt.Errorf("unknown signature scheme %d", uint8(id))
+	}
+	return []byte(id.String()), nil
+}
+
+func (id *SchemeID) UnmarshalText(text []byte) error {
+	s, err := SchemeByName(string(text))
+	if err != nil {
+		return err
+	}
+	*id = s
+	return nil
+}
+
+func (id SchemeID) circl() (sign.Scheme, error) {
+	s, ok := schemes[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown signature scheme %d", uint8(id))
+	}
+	return s.scheme, nil
+}
+
+// SeedSize returns the size of the seed keys are derived from, or 0 for an
+// unknown scheme. The same applies to the other size accessors.
+func (id SchemeID) SeedSize() int {
+	if s, err := id.circl(); err == nil {
+		return s.SeedSize()
+	}
+	return 0
+}
+
+func (id SchemeID) PrivateKeySize() int {
+	if s, err := id.circl(); err == nil {
+		return s.PrivateKeySize()
+	}
+	return 0
+}
+
+func (id SchemeID) PublicKeySize() int {
+	if s, err := id.circl(); err == nil {
+		return s.PublicKeySize()
+	}
+	return 0
+}
+
+func (id SchemeID) SignatureSize() int {
+	if s, err := id.circl(); err == nil {
+		return s.SignatureSize()
+	}
+	return 0
+}
+
+// NewKeyFromSeed deterministically derives a key pair from seed.
+func (id SchemeID) NewKeyFromSeed(seed []byte) (Signer, error) {
+	s, err := id.circl()
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != s.SeedSize() {
+		return nil, fmt.Errorf("%s seed must be %d bytes", id, s.SeedSize())
+	}
+	pk, sk := s.DeriveKey(seed)
+	return &circlSigner{id: id, scheme: s, sk: sk, pk: pk}, nil
+}
+
+// UnmarshalPrivateKey decodes a packed private key.
+func (id SchemeID) UnmarshalPrivateKey(data []byte) (Signer, error) {
+	s, err := id.circl()
+	if err != nil {
+		return nil, err
+	}
+	sk, err := s.UnmarshalBinaryPrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	pk, ok := sk.Public().(sign.PublicKey)
+	if !ok {
+		return nil, errors.New("private key has no usable public key")
+	}
+	return &circlSigner{id: id, scheme: s, sk: sk, pk: pk}, nil
+}
+
+// UnmarshalPublicKey decodes a packed public key.
+func (id SchemeID) UnmarshalPublicKey(data []byte) (Verifier, error) {
+	s, err := id.circl()
+	if err != nil {
+		return nil, err
+	}
+	pk, err := s.UnmarshalBinaryPublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &circlVerifier{id: id, scheme: s, pk: pk}, nil
+}
+
+// circlSigner adapts a circl private key to Signer.
+type circlSigner struct {
+	id     SchemeID
+	scheme sign.Scheme
+	sk     sign.PrivateKey
+	pk     sign.PublicKey
+}
+
+func (s *circlSigner) Scheme() SchemeID { return s.id }
+
+func (s *circlSigner) Sign(msg []byte) ([]byte, error) {
+	return s.scheme.Sign(s.sk, msg, nil), nil
+}
+
+func (s *circlSigner) Public() Verifier {
+	return &circlVerifier{id: s.id, scheme: s.scheme, pk: s.pk}
+}
+
+func (s *circlSigner) MarshalBinary() ([]byte, error) {
+	return s.sk.MarshalBinary()
+}
+
+// circlVerifier adapts a circl public key to Verifier.
+type circlVerifier struct {
+	id     SchemeID
+	scheme sign.Scheme
+	pk     sign.PublicKey
+}
+
+func (v *circlVerifier) Scheme() SchemeID { return v.id }
+
+func (v *circlVerifier) Verify(msg, sig []byte) bool {
+	return v.scheme.Verify(v.pk, msg, sig, nil)
+}
+
+func (v *circlVerifier) MarshalBinary() ([]byte, error) {
+	return v.pk.MarshalBinary()
+}
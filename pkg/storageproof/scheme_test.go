@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+func TestSchemesSignAndVerify(t *testing.T) {
+	challengeHash := make([]byte, 32)
+	_, err := rand.Read(challengeHash)
+	if err != nil {
+		t.Fatalf("Failed to create challenge hash: %v", err)
+	}
+
+	for _, id := range []SchemeID{SchemeMLDSA87, SchemeMLDSA65, SchemeMLDSA44, SchemeEd25519} {
+		seed := make([]byte, id.SeedSize())
+		_, err = rand.Read(seed)
+		if err != nil {
+			t.Fatalf("Failed to create seed: %v", err)
+		}
+
+		sk, err := id.NewKeyFromSeed(seed)
+		if err != nil {
+			t.Fatalf("%s: failed to derive key: %v", id, err)
+		}
+
+		solution, err := NewSolutionWithSigner(challengeHash, 3, sk)
+		if err != nil {
+			t.Fatalf("%s: failed to create solution: %v", id, err)
+		}
+
+		// The scheme must survive a JSON round trip
+		data, err := json.Marshal(solution)
+		if err != nil {
+			t.Fatalf("%s: failed to marshal solution: %v", id, err)
+		}
+		var decoded Solution
+		err = json.Unmarshal(data, &decoded)
+		if err != nil {
+			t.Fatalf("%s: failed to unmarshal solution: %v", id, err)
+		}
+		if decoded.Scheme != id {
+			t.Errorf("%s: expected scheme to round trip, got %s", id, decoded.Scheme)
+		}
+
+		valid, err := decoded.Verify()
+		if err != nil {
+			t.Fatalf("%s: failed to verify solution: %v", id, err)
+		}
+		if !valid {
+			t.Errorf("%s: expected solution to be valid, but it was not", id)
+		}
+	}
+}
@@ -4,10 +4,11 @@
 package storageproof
 
 import (
+	"bytes"
 	"crypto"
-	"crypto/rand"
 	"encoding/ascii85"
 	"errors"
+	"fmt"
 
 	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
 )
@@ -16,14 +17,55 @@ import (
 // All binary data is encoded as base85 strings for long-term storage
 
 type Solution struct {
-	Hash      string `json:"hash"`
-	Distance  int    `json:"distance"`
-	PublicKey string `json:"public_key"`
-	Signature string `json:"signature"`
+	Hash       string      `json:"hash"`
+	Distance   int         `json:"distance"`
+	Scheme     SchemeID    `json:"scheme,omitempty"` // omitted for ML-DSA-87
+	HashParams *HashParams `json:"hash_params,omitempty"`
+	FarmerKey  string      `json:"farmer_key,omitempty"` // set when the plot is bound to a farmer
+	PoolKey    string      `json:"pool_key,omitempty"`
+	PlotID     string      `json:"plot_id,omitempty"` // the plot the key came from, when known
+	// Proof, when set, proves the key is in the plot PlotID names.
+	Proof     *InclusionProof `json:"proof,omitempty"`
+	PublicKey string          `json:"public_key"`
+	Signature string          `json:"signature"`
+}
+
+// InclusionProof is the Merkle inclusion path of a solution's hash in the key
+// table of its plot, whose root is the plot ID.
+type InclusionProof struct {
+	Index   uint32 `json:"index"`    // position of the key in the table
+	NumKeys uint32 `json:"num_keys"` // keys in the plot
+	Path    string `json:"path"`     // sibling hashes from the leaf up
+}
+
+// maxProofLen is the length of the longest inclusion path, that of a plot
+// with 2^32 keys.
+const maxProofLen = 32
+
+// ErrMalformedSolution is wrapped by verification errors caused by a solution
+// that can't be decoded, as opposed to one that decodes but is rejected.
+var ErrMalformedSolution = errors.New("malformed solution")
+
+// VerifyOptions controls the checks made by VerifyWithOptions.
+type VerifyOptions struct {
+	// HashPolicy decides whether declared hash parameters are acceptable.
+	// Nil uses DefaultHashPolicy.
+	HashPolicy HashPolicy
+	// RequireHashParams rejects solutions that do not declare hash parameters
+	// and so cannot have their hash recomputed.
+	RequireHashParams bool
+	// FarmerKey, when set, rejects solutions from plots bound to any other farmer.
+	FarmerKey []byte
+	// PlotRegistered, when set, requires solutions to prove their key is in
+	// a plot it accepts the ID of, such as one registered on chain. Such
+	// solutions must declare hash parameters.
+	PlotRegistered func(id PlotID) bool
 }
 
-const shakeOutputLen = 64 // 512 bits for 256-bit security
 // Shake256SignerOpts implements crypto.SignerOpts for SHAKE256.
+//
+// Deprecated: solutions are signed through a Signer, which doesn't take
+// signer options. It is unused and kept only for compatibility.
 type Shake256SignerOpts struct {
 	// The desired output length in bytes.
 	// For 256-bit security, use at least 64 bytes (512 bits) of output.
@@ -37,74 +79,155 @@ func (o Shake256SignerOpts) HashFunc() crypto.Hash {
 	return 0
 }
 
+// NewSolution signs challengeHash with an ML-DSA-87 private key.
 func NewSolution(challengeHash []byte, distance int, sk *mldsa87.PrivateKey) (*Solution, error) {
-	pk := sk.Public().(*mldsa87.PublicKey)
+	if sk == nil {
+		return nil, errors.New("sk cannot be nil")
+	}
+	return NewSolutionWithSigner(challengeHash, distance, &circlSigner{
+		id:     SchemeMLDSA87,
+		scheme: mldsa87.Scheme(),
+		sk:     sk,
+		pk:     sk.Public().(*mldsa87.PublicKey),
+	})
+}
 
-	pkBytes, err := pk.MarshalBinary()
-	if err != nil {
-		return nil, err
+// NewSolutionWithSigner signs challengeHash with a key of any supported scheme.
+func NewSolutionWithSigner(challengeHash []byte, distance int, sk Signer) (*Solution, error) {
+	if sk == nil {
+		return nil, errors.New("sk cannot be nil")
 	}
 	if len(challengeHash) != 32 {
 		return nil, errors.New("challenge hash length must be 32 bytes")
 	}
-	if sk == nil {
-		return nil, errors.New("sk cannot be nil")
+
+	pkBytes, err := sk.Public().MarshalBinary()
+	if err != nil {
+		return nil, err
 	}
-	opts := &Shake256SignerOpts{OutputLen: shakeOutputLen}
-	sig, err := sk.Sign(rand.Reader, challengeHash, opts)
+	sig, err := sk.Sign(challengeHash)
 	if err != nil {
 		return nil, err
 	}
 
-	hashDst := make([]byte, ascii85.MaxEncodedLen(len(challengeHash)))
-	encoded := ascii85.Encode(hashDst, challengeHash)
-	hashDst = hashDst[:encoded]
-
-	pkDst := make([]byte, ascii85.MaxEncodedLen(len(pkBytes)))
-	encoded = ascii85.Encode(pkDst, pkBytes)
-	pkDst = pkDst[:encoded]
-
-	sigDst := make([]byte, ascii85.MaxEncodedLen(len(sig)))
-	encoded = ascii85.Encode(sigDst, sig)
-	sigDst = sigDst[:encoded]
-
 	return &Solution{
-		Hash:      string(hashDst),
+		Hash:      encodeAscii85(challengeHash),
 		Distance:  distance,
-		PublicKey: string(pkDst),
-		Signature: string(sigDst),
+		Scheme:    sk.Scheme(),
+		PublicKey: encodeAscii85(pkBytes),
+		Signature: encodeAscii85(sig),
 	}, nil
 }
 
+// bind records the keys of the plot the solution came from so that Verify can
+// check the plot's farmer binding, and the plot's ID.
+func (s *Solution) bind(h *Header) {
+	hashParams := h.HashParams
+	s.HashParams = &hashParams
+	s.FarmerKey = encodeAscii85(h.FarmerKey)
+	s.PoolKey = encodeAscii85(h.PoolKey)
+	if h.ID != (PlotID{}) {
+		s.PlotID = encodeAscii85(h.ID[:])
+	}
+}
+
+// Verify checks the solution signature, that its inclusion proof if any leads
+// to its plot ID and, when the solution declares hash parameters, that its
+// hash is the hash of its public key.
 func (s *Solution) Verify() (bool, error) {
-	hashBytes := make([]byte, 32)
-	decoded, _, err := ascii85.Decode(hashBytes, []byte(s.Hash), true)
+	return s.VerifyWithOptions(VerifyOptions{})
+}
+
+// VerifyWithOptions is Verify with control over which hash parameters are accepted.
+func (s *Solution) VerifyWithOptions(opts VerifyOptions) (bool, error) {
+	return s.verify(opts, nil)
+}
+
+// verify implements VerifyWithOptions. cache, when set, is used to recompute
+// the plot hash so repeated public keys are only hashed once.
+func (s *Solution) verify(opts VerifyOptions, cache *hashCache) (bool, error) {
+	r, err := s.raw()
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %w", ErrMalformedSolution, err)
 	}
-	hashBytes = hashBytes[:decoded]
 
-	pkBytes := make([]byte, mldsa87.PublicKeySize)
-	decoded, _, err = ascii85.Decode(pkBytes, []byte(s.PublicKey), true)
-	if err != nil {
-		return false, err
+	if r.HashParams == nil && (opts.RequireHashParams || opts.PlotRegistered != nil) {
+		return false, errors.New("solution does not declare hash parameters")
+	}
+	if r.HashParams != nil {
+		policy := opts.HashPolicy
+		if policy == nil {
+			policy = DefaultHashPolicy
+		}
+		if err := policy(*r.HashParams); err != nil {
+			return false, err
+		}
 	}
-	pkBytes = pkBytes[:decoded]
 
-	pk := &mldsa87.PublicKey{}
-	err = pk.UnmarshalBinary(pkBytes)
-	if err != nil {
-		return false, err
+	if len(r.FarmerKey) != 0 && r.HashParams == nil {
+		return false, fmt.Errorf("%w: farmer binding can't be checked without hash parameters", ErrMalformedSolution)
+	}
+	if opts.FarmerKey != nil && !bytes.Equal(opts.FarmerKey, r.FarmerKey) {
+		return false, nil
+	}
+
+	if r.Proof != nil && r.HashParams == nil {
+		// Without the key hash recomputed, any key could sign a hash lifted
+		// from someone else's plot along with its proof
+		return false, fmt.Errorf("%w: inclusion proof can't be checked without hash parameters", ErrMalformedSolution)
+	}
+	if r.Proof == nil && opts.PlotRegistered != nil {
+		return false, errors.New("solution does not prove which plot its key is in")
+	}
+	if r.Proof != nil {
+		if !verifyMerklePath(PlotID(r.PlotID), [32]byte(r.Hash), r.Proof.Index, r.Proof.NumKeys, r.Proof.Path) {
+			return false, nil
+		}
+		if opts.PlotRegistered != nil && !opts.PlotRegistered(PlotID(r.PlotID)) {
+			return false, nil
+		}
 	}
 
-	sigBytes := make([]byte, 1024*8) // Allocate a large enough buffer for the signature
-	decoded, _, err = ascii85.Decode(sigBytes, []byte(s.Signature), true)
+	pk, err := r.Scheme.UnmarshalPublicKey(r.PublicKey)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("%w: %w", ErrMalformedSolution, err)
 	}
-	sigBytes = sigBytes[:decoded]
+	if !pk.Verify(r.Hash, r.Signature) {
+		return false, nil
+	}
+
+	if r.HashParams != nil {
+		// Recompute the plot hash so a solution can't claim a key it didn't
+		// plot, or a plot bound to another farmer
+		expected, err := cache.sum(r)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(expected[:], r.Hash) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
-	return mldsa87.Verify(pk, hashBytes, nil, sigBytes), nil
+// encodeAscii85 encodes src as an ascii85 string.
+func encodeAscii85(src []byte) string {
+	dst := make([]byte, ascii85.MaxEncodedLen(len(src)))
+	return string(dst[:ascii85.Encode(dst, src)])
+}
+
+// decodeAscii85 decodes an ascii85 string that should hold at most maxLen bytes.
+func decodeAscii85(src string, maxLen int) ([]byte, error) {
+	// ascii85.Decode only writes whole 4-byte groups while dst has room for them
+	dst := make([]byte, maxLen+4)
+	decoded, consumed, err := ascii85.Decode(dst, []byte(src), true)
+	if err != nil {
+		return nil, err
+	}
+	if consumed != len(src) || decoded > maxLen {
+		return nil, errors.New("ascii85 value too long")
+	}
+	return dst[:decoded], nil
 }
 
 // BestMatch returns the best solution from a slice of solutions
@@ -5,6 +5,7 @@ package storageproof
 
 import (
 	"crypto/rand"
+	"errors"
 	"testing"
 
 	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
@@ -66,3 +67,16 @@ func TestNewSolutionAndVerify(t *testing.T) {
 		t.Errorf("Expected solution to be invalid, but it was valid")
 	}
 }
+
+func TestVerifyMalformedSolution(t *testing.T) {
+	for _, solution := range []*Solution{
+		{Hash: "not ascii85 ~~~", PublicKey: "", Signature: ""},
+		{Hash: "", Scheme: SchemeID(200)},
+		{Hash: "", PublicKey: "!!!!"},
+	} {
+		_, err := solution.Verify()
+		if !errors.Is(err, ErrMalformedSolution) {
+			t.Errorf("Expected ErrMalformedSolution for %+v, got %v", solution, err)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// partialSuffix marks a plot that is still being written or copied into
+// place. LoadPlots only loads .plot files, so partial plots are never farmed.
+const partialSuffix = ".partial"
+
+// stagePlot creates the plot described by h in opts.TmpDir and returns its
+// path. The plot keeps its partial name until unstagePlot moves it, so a
+// staging directory inside a farmed directory never holds a second copy.
+func stagePlot(h *Header, opts PlotOptions) (string, error) {
+	if err := checkFreeSpace(opts.Store, opts.TmpDir, plotFileSize(h)); err != nil {
+		return "", err
+	}
+	return writePartialPlot(opts.TmpDir, h, opts)
+}
+
+// unstagePlot moves the plot stagePlot created into destDir, under its final
+// name, and returns its new path. A plot that can't be moved is given its
+// final name where it was staged, so it can be moved by hand.
+func unstagePlot(store PlotStore, partial string, h *Header, destDir string) (string, error) {
+	store = storeOrOS(store)
+	name := plotFileName(h.ID)
+	path, err := movePlot(store, partial, filepath.Join(destDir, name))
+	if err != nil {
+		_ = store.Rename(partial, filepath.Join(filepath.Dir(partial), name))
+		return "", err
+	}
+	return path, nil
+}
+
+// MovePlot moves a finished plot into destDir and returns its new path. The
+// plot's header and size are checked first. Within a filesystem the plot is
+// renamed; across filesystems it is copied under a temporary name, the SHA3-256
+// checksum of the copy read back from destDir must match that of the original,
+// and only then is it renamed into place and the original removed. Either way
+// the plot appears in destDir atomically. A failed copy is removed and the
+// original kept.
+func MovePlot(src, destDir string) (string, error) {
+	return MovePlotIn(nil, src, destDir)
+}
+
+// MovePlotIn is MovePlot within store.
+func MovePlotIn(store PlotStore, src, destDir string) (string, error) {
+	return movePlot(storeOrOS(store), src, filepath.Join(destDir, filepath.Base(src)))
+}
+
+// movePlot moves the plot src of store to dest as MovePlot does.
+func movePlot(store PlotStore, src, dest string) (string, error) {
+	if err := checkPlotFile(store, src); err != nil {
+		return "", err
+	}
+
+	if _, err := store.Stat(dest); err == nil {
+		return "", fmt.Errorf("%s already exists", dest)
+	}
+	if err := store.Rename(src, dest); err == nil {
+		return dest, nil
+	}
+
+	partial := dest + partialSuffix
+	sum, err := copyPlot(store, src, partial)
+	if err != nil {
+		_ = store.Remove(partial)
+		return "", err
+	}
+	check, err := fileChecksum(store, partial)
+	if err != nil {
+		_ = store.Remove(partial)
+		return "", err
+	}
+	if !bytes.Equal(sum, check) {
+		_ = store.Remove(partial)
+		return "", fmt.Errorf("checksum mismatch copying %s to %s", src, filepath.Dir(dest))
+	}
+
+	if err := store.Rename(partial, dest); err != nil {
+		_ = store.Remove(partial)
+		return "", err
+	}
+	if err := store.Remove(src); err != nil {
+		return dest, err
+	}
+	return dest, nil
+}
+
+// checkPlotFile checks that path of store holds a complete plot.
+func checkPlotFile(store PlotStore, path string) error {
+	file, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func(file PlotFile) {
+		_ = file.Close()
+	}(file)
+
+	h, err := ReadHeader(fileReader(file))
+	if err != nil {
+		return fmt.Errorf("error reading plot %s: %w", path, err)
+	}
+	info, err := store.Stat(path)
+	if err != nil {
+		return err
+	}
+	if size := plotFileSize(h); uint64(info.Size()) != size {
+		return fmt.Errorf("plot %s is %d bytes, expected %d", path, info.Size(), size)
+	}
+	return nil
+}
+
+// copyPlot copies src to dest, syncing it to disk, and returns the SHA3-256
+// checksum of the data read from src.
+func copyPlot(store PlotStore, src, dest string) ([]byte, error) {
+	in, err := store.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer func(in PlotFile) {
+		_ = in.Close()
+	}(in)
+
+	out, err := store.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	h := sha3.New256()
+	if _, err := io.CopyBuffer(io.MultiWriter(io.NewOffsetWriter(out, 0), h), fileReader(in), make([]byte, writeBufferSize)); err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if s, ok := out.(interface{ Sync() error }); ok {
+		if err := s.Sync(); err != nil {
+			_ = out.Close()
+			return nil, err
+		}
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// fileChecksum returns the SHA3-256 checksum of the file at path of store.
+func fileChecksum(store PlotStore, path string) ([]byte, error) {
+	file, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file PlotFile) {
+		_ = file.Close()
+	}(file)
+
+	h := sha3.New256()
+	if _, err := io.CopyBuffer(h, fileReader(file), make([]byte, writeBufferSize)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlotStaging(t *testing.T) {
+	tmpDir, destDir := t.TempDir(), t.TempDir()
+	opts := PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+		TmpDir:     tmpDir,
+	}
+
+	path, err := PlotWithOptions(destDir, 1, opts)
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	if filepath.Dir(path) != destDir {
+		t.Errorf("Plot %s is not in %s", path, destDir)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 0 {
+		t.Errorf("Plot was left in the temporary directory")
+	}
+
+	// A staged plot isn't loaded until it's moved, even from a staging
+	// directory inside the destination
+	opts.TmpDir = filepath.Join(destDir, ".staging")
+	if err := os.Mkdir(opts.TmpDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	h, err := newPlotHeader(1, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := stagePlot(h, opts)
+	if err != nil {
+		t.Fatalf("Failed to stage plot: %v", err)
+	}
+	if pc, err := LoadPlots([]string{destDir}, false); err != nil || len(pc.Plots) != 1 {
+		t.Errorf("Expected only the finished plot to load: %v", err)
+	}
+	moved, err := unstagePlot(nil, partial, h, destDir)
+	if err != nil || moved != filepath.Join(destDir, plotFileName(h.ID)) {
+		t.Fatalf("Failed to move the staged plot to %s: %v", moved, err)
+	}
+	if pc, err := LoadPlots([]string{destDir}, false); err != nil || len(pc.Plots) != 2 {
+		t.Errorf("Expected both plots to load: %v", err)
+	}
+
+	// A copy across filesystems is read back and checked
+	staged := filepath.Join(tmpDir, "copy.plot")
+	sum, err := copyPlot(OSStore{}, path, staged)
+	if err != nil {
+		t.Fatalf("Failed to copy plot: %v", err)
+	}
+	if check, err := fileChecksum(OSStore{}, staged); err != nil || string(check) != string(sum) {
+		t.Errorf("Copy checksum mismatch: %v", err)
+	}
+
+	// Incomplete plots are not moved into place
+	if err := os.Truncate(staged, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MovePlot(staged, destDir); err == nil {
+		t.Errorf("Expected a truncated plot to be rejected")
+	}
+}
+
+// deviceStore is a limitedStore whose top-level directories are separate
+// filesystems, so plots are copied between them rather than renamed.
+type deviceStore struct {
+	limitedStore
+	failIn  string // Create fails in this directory
+	corrupt bool   // copies have their first byte flipped
+}
+
+var errDeviceFull = errors.New("device full")
+
+func storeDevice(name string) string {
+	return strings.SplitN(memName(name), "/", 2)[0]
+}
+
+func (s *deviceStore) Create(name string) (PlotFileWriter, error) {
+	if storeDevice(name) == s.failIn {
+		return nil, errDeviceFull
+	}
+	f, err := s.limitedStore.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	if s.corrupt && strings.HasSuffix(name, partialSuffix) {
+		return corruptWriter{f}, nil
+	}
+	return f, nil
+}
+
+func (s *deviceStore) Rename(oldName, newName string) error {
+	if storeDevice(oldName) != storeDevice(newName) {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: errors.ErrUnsupported}
+	}
+	return s.limitedStore.Rename(oldName, newName)
+}
+
+// corruptWriter flips the first byte written.
+type corruptWriter struct {
+	PlotFileWriter
+}
+
+func (w corruptWriter) WriteAt(p []byte, off int64) (int, error) {
+	if off == 0 && len(p) > 0 {
+		p = bytes.Clone(p)
+		p[0] ^= 1
+	}
+	return w.PlotFileWriter.WriteAt(p, off)
+}
+
+func TestPlotSeriesStaging(t *testing.T) {
+	opts := PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+		TmpDir:     "tmp",
+	}
+	h, err := newPlotHeader(1, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := plotFileSize(h)
+	newStore := func() *deviceStore {
+		s := &deviceStore{limitedStore: limitedStore{NewMemStore(), map[string]uint64{"tmp": 3 * size, "dest": 10 * size}}}
+		opts.Store = s
+		return s
+	}
+
+	// Room for three plots in tmp is enough: one being created, one waiting
+	// and one being copied
+	store := newStore()
+	paths, err := PlotSeries("dest", 5, FillOptions{PlotOptions: opts, KValue: 1})
+	if err != nil {
+		t.Fatalf("Failed to plot series: %v", err)
+	}
+	if len(paths) != 5 {
+		t.Errorf("Expected 5 plots, got %v", paths)
+	}
+	if names, _ := store.List("dest"); !slices.Equal(names, slices.Sorted(slices.Values(paths))) {
+		t.Errorf("Expected only the plots in dest, got %v", names)
+	}
+	if names, _ := store.List("tmp"); len(names) != 0 {
+		t.Errorf("Expected tmp to be empty, got %v", names)
+	}
+
+	// A failed move ends the series with its error and no partial copy
+	store = newStore()
+	store.failIn = "dest"
+	paths, err = PlotSeries("dest", 5, FillOptions{PlotOptions: opts, KValue: 1})
+	if !errors.Is(err, errDeviceFull) || len(paths) != 0 {
+		t.Errorf("Expected the move error and no plots, got %v and %v", err, paths)
+	}
+	if names, _ := store.List("dest"); len(names) != 0 {
+		t.Errorf("Expected nothing in dest, got %v", names)
+	}
+	names, _ := store.List("tmp")
+	if len(names) == 0 || len(names) > 3 {
+		t.Errorf("Expected the staged plots to be kept, got %v", names)
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".plot") {
+			t.Errorf("Expected a kept plot to have its final name, got %s", name)
+		}
+	}
+
+	// A copy that doesn't match the original is removed
+	store = newStore()
+	staged, err := stagePlot(h, opts)
+	if err != nil {
+		t.Fatalf("Failed to stage plot: %v", err)
+	}
+	store.corrupt = true
+	if _, err := MovePlotIn(store, staged, "dest"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+	if names, _ := store.List("dest"); len(names) != 0 {
+		t.Errorf("Expected the corrupt copy to be removed, got %v", names)
+	}
+	if _, err := store.Stat(staged); err != nil {
+		t.Errorf("Expected the original to be kept: %v", err)
+	}
+}
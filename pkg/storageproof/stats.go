@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"crypto/rand"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultStatsSamples is how many challenges Stats looks up by default.
+const defaultStatsSamples = 100
+
+// FarmStats summarises a plot collection, see PlotCollection.Stats.
+type FarmStats struct {
+	Plots     int    `json:"plots"`
+	TotalKeys uint64 `json:"total_keys"`
+	// TotalBytes is the size of the plot files.
+	TotalBytes uint64 `json:"total_bytes"`
+	// Dirs and Devices break the plots down by directory and, for plots in
+	// the local filesystem, the device holding them, in path order.
+	Dirs    []SpaceStats `json:"dirs"`
+	Devices []SpaceStats `json:"devices,omitempty"`
+	// ExpectedBestDistance is the mean best distance to a random challenge
+	// for a farm of TotalKeys keys.
+	ExpectedBestDistance float64 `json:"expected_best_distance"`
+	// Samples random challenges were looked up; their best distances have
+	// the mean SampledBestDistance and are counted in Histogram.
+	Samples             int             `json:"samples"`
+	SampledBestDistance float64         `json:"sampled_best_distance"`
+	Histogram           []DistanceCount `json:"histogram"`
+}
+
+// SpaceStats is the share of a farm held in a directory or on a device.
+type SpaceStats struct {
+	Path   string `json:"path"`             // directory, or the first on the device
+	Device string `json:"device,omitempty"` // empty when unknown
+	Plots  int    `json:"plots"`
+	Keys   uint64 `json:"keys"`
+	Bytes  uint64 `json:"bytes"`
+}
+
+// DistanceCount is a bin of the best distance histogram.
+type DistanceCount struct {
+	Distance int `json:"distance"`
+	Count    int `json:"count"`
+}
+
+// StatsOptions controls StatsWithOptions.
+type StatsOptions struct {
+	// Samples is how many random challenges are looked up for the
+	// histogram, 100 when zero.
+	Samples int
+	// Rand is the source of the challenges, crypto/rand when nil.
+	Rand io.Reader
+}
+
+// Stats summarises the collection: its plots, keys and bytes, where they
+// are stored, and the best distances it finds.
+func (pc *PlotCollection) Stats() (*FarmStats, error) {
+	return pc.StatsWithOptions(StatsOptions{})
+}
+
+// StatsWithOptions is Stats with control over the sampled lookups.
+func (pc *PlotCollection) StatsWithOptions(opts StatsOptions) (*FarmStats, error) {
+	samples := opts.Samples
+	if samples <= 0 {
+		samples = defaultStatsSamples
+	}
+	rng := opts.Rand
+	if rng == nil {
+		rng = rand.Reader
+	}
+
+	stats := &FarmStats{Plots: len(pc.Plots), TotalKeys: pc.TotalKeys(), Dirs: []SpaceStats{}, Histogram: []DistanceCount{}}
+	dirs := make(map[string]*SpaceStats)
+	for _, plot := range pc.Plots {
+		size := plotFileSize(plot.Header)
+		stats.TotalBytes += size
+
+		dir := filepath.Dir(plot.Path)
+		d, ok := dirs[dir]
+		if !ok {
+			d = &SpaceStats{Path: dir}
+			if _, local := storeOrOS(plot.store).(OSStore); local {
+				d.Device, _ = deviceOf(dir)
+			}
+			dirs[dir] = d
+		}
+		d.Plots++
+		d.Keys += uint64(plot.NumKeys)
+		d.Bytes += size
+	}
+
+	devices := make(map[string]*SpaceStats)
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		d := dirs[dir]
+		stats.Dirs = append(stats.Dirs, *d)
+		if d.Device == "" {
+			continue
+		}
+		dev, ok := devices[d.Device]
+		if !ok {
+			dev = &SpaceStats{Path: d.Path, Device: d.Device}
+			devices[d.Device] = dev
+		}
+		dev.Plots += d.Plots
+		dev.Keys += d.Keys
+		dev.Bytes += d.Bytes
+	}
+	for _, dev := range devices {
+		stats.Devices = append(stats.Devices, *dev)
+	}
+	slices.SortFunc(stats.Devices, func(a, b SpaceStats) int { return strings.Compare(a.Path, b.Path) })
+
+	stats.ExpectedBestDistance = ExpectedBestDistance(stats.TotalKeys)
+	if stats.TotalKeys == 0 {
+		return stats, nil
+	}
+	counts := make(map[int]int)
+	challenge := make([]byte, 32)
+	var total int
+	for range samples {
+		if _, err := io.ReadFull(rng, challenge); err != nil {
+			return nil, err
+		}
+		_, _, distance := pc.closest(challenge)
+		counts[distance]++
+		total += distance
+	}
+	stats.Samples = samples
+	stats.SampledBestDistance = float64(total) / float64(samples)
+	for distance, count := range counts {
+		stats.Histogram = append(stats.Histogram, DistanceCount{Distance: distance, Count: count})
+	}
+	slices.SortFunc(stats.Histogram, func(a, b DistanceCount) int { return a.Distance - b.Distance })
+	return stats, nil
+}
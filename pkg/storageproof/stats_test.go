@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	dir := t.TempDir()
+	var want uint64
+	for _, sub := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path, err := PlotWithOptions(filepath.Join(dir, sub), 1, PlotOptions{
+			Format:     FormatSeed,
+			Scheme:     SchemeEd25519,
+			HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+		})
+		if err != nil {
+			t.Fatalf("Failed to plot: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want += uint64(info.Size())
+	}
+	pc, err := LoadPlots([]string{dir}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := pc.StatsWithOptions(StatsOptions{Samples: 200, Rand: goldenRand("storageproof stats")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Plots != 2 || stats.TotalKeys != 2000 || stats.TotalBytes != want {
+		t.Errorf("Expected 2 plots, 2000 keys and %d bytes, got %+v", want, stats)
+	}
+	if len(stats.Dirs) != 2 || stats.Dirs[0].Path != filepath.Join(dir, "a") || stats.Dirs[0].Keys != 1000 {
+		t.Errorf("Unexpected directories %+v", stats.Dirs)
+	}
+	if len(stats.Devices) > 1 || len(stats.Devices) == 1 && stats.Devices[0].Plots != 2 {
+		t.Errorf("Expected both plots on one device, got %+v", stats.Devices)
+	}
+
+	var samples int
+	for _, bin := range stats.Histogram {
+		samples += bin.Count
+	}
+	if stats.Samples != 200 || samples != 200 {
+		t.Errorf("Expected 200 samples in the histogram, got %d", samples)
+	}
+	if math.Abs(stats.SampledBestDistance-stats.ExpectedBestDistance) > 2 {
+		t.Errorf("Sampled best distance %f is far from the expected %f", stats.SampledBestDistance, stats.ExpectedBestDistance)
+	}
+}
@@ -0,0 +1,334 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PlotStore is where plot files are kept. Plotting, loading and lookups go
+// through a store, so plots can live somewhere other than the local disk.
+// Functions given a nil store use OSStore.
+type PlotStore interface {
+	// Open opens a file for reading.
+	Open(name string) (PlotFile, error)
+	// Create creates a file for writing, truncating it if it exists.
+	Create(name string) (PlotFileWriter, error)
+	// List returns the names of the files in dir and its subdirectories in
+	// lexical order, or just dir if it is a file.
+	List(dir string) ([]string, error)
+	Stat(name string) (fs.FileInfo, error)
+	Rename(oldName, newName string) error
+	Remove(name string) error
+}
+
+// PlotFile is a file opened for reading from a PlotStore.
+type PlotFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// PlotFileWriter is a file created in a PlotStore. Data that must be durable
+// once the file is closed is synced first if it has a Sync method.
+type PlotFileWriter interface {
+	io.WriterAt
+	io.Closer
+}
+
+// storeOrOS returns store, or OSStore when it is nil.
+func storeOrOS(store PlotStore) PlotStore {
+	if store == nil {
+		return OSStore{}
+	}
+	return store
+}
+
+// storeFreeSpace returns the space available in dir of store, or
+// errors.ErrUnsupported if the store can't tell.
+func storeFreeSpace(store PlotStore, dir string) (uint64, error) {
+	if s, ok := storeOrOS(store).(interface {
+		FreeSpace(dir string) (uint64, error)
+	}); ok {
+		return s.FreeSpace(dir)
+	}
+	return 0, errors.ErrUnsupported
+}
+
+// fileReader returns a reader over the whole of file.
+func fileReader(file PlotFile) io.Reader {
+	return io.NewSectionReader(file, 0, math.MaxInt64)
+}
+
+// OSStore keeps plots in the local filesystem, naming them by OS paths.
+type OSStore struct{}
+
+func (OSStore) Open(name string) (PlotFile, error) {
+	return os.Open(name)
+}
+
+func (OSStore) Create(name string) (PlotFileWriter, error) {
+	return os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+}
+
+func (OSStore) List(dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Plots may be links to files elsewhere, as in the walk before
+			// stores, but linked directories aren't followed
+			if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+				names = append(names, name)
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+func (OSStore) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSStore) Rename(oldName, newName string) error {
+	return os.Rename(oldName, newName)
+}
+
+func (OSStore) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// FreeSpace returns the space available in dir, or errors.ErrUnsupported
+// on platforms that can't query it.
+func (OSStore) FreeSpace(dir string) (uint64, error) {
+	return freeSpace(dir)
+}
+
+// MemStore keeps plots in memory, for tests and short-lived plots. Names are
+// cleaned slash-separated paths; directories exist implicitly.
+type MemStore struct {
+	mu    sync.Mutex
+	files map[string]*memFile
+}
+
+// NewMemStore returns an empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{files: make(map[string]*memFile)}
+}
+
+func memName(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+func (m *MemStore) Open(name string) (PlotFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[memName(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (m *MemStore) Create(name string) (PlotFileWriter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = memName(name)
+	f := &memFile{name: path.Base(name), modTime: time.Now()}
+	m.files[name] = f
+	return f, nil
+}
+
+func (m *MemStore) List(dir string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	dir = memName(dir)
+	var names []string
+	for name := range m.files {
+		if dir == "." || name == dir || strings.HasPrefix(name, dir+"/") {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (m *MemStore) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[memName(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.stat(), nil
+}
+
+func (m *MemStore) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[memName(oldName)]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrNotExist}
+	}
+	delete(m.files, memName(oldName))
+	f.mu.Lock()
+	f.name = path.Base(memName(newName))
+	f.mu.Unlock()
+	m.files[memName(newName)] = f
+	return nil
+}
+
+func (m *MemStore) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[memName(name)]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, memName(name))
+	return nil
+}
+
+// memFile is a MemStore file, shared by every handle opened on it.
+type memFile struct {
+	mu      sync.RWMutex
+	name    string
+	data    []byte
+	modTime time.Time
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if end := int(off) + len(p); end > len(f.data) {
+		f.data = slices.Grow(f.data, end-len(f.data))[:end]
+	}
+	f.modTime = time.Now()
+	return copy(f.data[off:], p), nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+func (f *memFile) stat() fs.FileInfo {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return memFileInfo{name: f.name, size: int64(len(f.data)), modTime: f.modTime}
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
+
+// FSStore serves plots read-only from an fs.FS, such as an archive or an
+// embedded directory. Names are fs.FS paths. Files that don't support random
+// access are read into memory when opened.
+type FSStore struct {
+	FS fs.FS
+}
+
+// NewFSStore returns an FSStore reading from fsys.
+func NewFSStore(fsys fs.FS) *FSStore {
+	return &FSStore{FS: fsys}
+}
+
+func (s *FSStore) Open(name string) (PlotFile, error) {
+	f, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if rf, ok := f.(PlotFile); ok {
+		return rf, nil
+	}
+	defer func(f fs.File) {
+		_ = f.Close()
+	}(f)
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return &memFile{name: path.Base(name), data: data}, nil
+}
+
+func (s *FSStore) Create(name string) (PlotFileWriter, error) {
+	return nil, &fs.PathError{Op: "create", Path: name, Err: errors.ErrUnsupported}
+}
+
+func (s *FSStore) List(dir string) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(s.FS, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Plots may be links to files elsewhere, as in the walk before
+			// stores, but linked directories aren't followed
+			if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+				names = append(names, name)
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+func (s *FSStore) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(s.FS, name)
+}
+
+func (s *FSStore) Rename(oldName, newName string) error {
+	return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: errors.ErrUnsupported}
+}
+
+func (s *FSStore) Remove(name string) error {
+	return &fs.PathError{Op: "remove", Path: name, Err: errors.ErrUnsupported}
+}
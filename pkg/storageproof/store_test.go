@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemStore(t *testing.T) {
+	store := NewMemStore()
+	opts := PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+		TmpDir:     "tmp",
+		Store:      store,
+	}
+
+	path, err := PlotWithOptions("plots", 1, opts)
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	if filepath.Dir(path) != "plots" {
+		t.Errorf("Plot %s is not in plots", path)
+	}
+	if names, _ := store.List("tmp"); len(names) != 0 {
+		t.Errorf("Plot was left in the temporary directory: %v", names)
+	}
+
+	pc, err := LoadPlotsWithOptions([]string{"plots"}, LoadOptions{Store: store})
+	if err != nil || len(pc.Plots) != 1 {
+		t.Fatalf("Failed to load plot from memory: %v", err)
+	}
+	solution, err := pc.LookUp(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("Failed to look up: %v", err)
+	}
+	if valid, err := solution.VerifyWithOptions(VerifyOptions{HashPolicy: PermissiveHashPolicy}); !valid || err != nil {
+		t.Errorf("Solution from memory is invalid: %v", err)
+	}
+
+	// Nothing was written to the local filesystem
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Plot %s exists on disk", path)
+	}
+}
+
+func TestFSStore(t *testing.T) {
+	data, err := os.ReadFile(goldenPlot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := LoadPlots([]string{goldenPlot}, false)
+	if err != nil || len(want.Plots) != 1 {
+		t.Fatalf("Failed to load %s: %v", goldenPlot, err)
+	}
+	challenge := make([]byte, 32)
+	best, err := want.LookUp(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A zip archive doesn't support random access to its files
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("plots/sp2-golden.plot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]PlotStore{
+		"dir": NewFSStore(os.DirFS(filepath.Dir(goldenPlot))),
+		"zip": NewFSStore(zr),
+	} {
+		pc, err := LoadPlotsWithOptions([]string{"."}, LoadOptions{Store: store})
+		if err != nil || len(pc.Plots) != 1 {
+			t.Fatalf("%s: failed to load plot: %v", name, err)
+		}
+		solution, err := pc.LookUp(challenge)
+		if err != nil {
+			t.Fatalf("%s: failed to look up: %v", name, err)
+		}
+		if solution.Signature != best.Signature {
+			t.Errorf("%s: solution differs from the plot on disk", name)
+		}
+
+		if _, err := store.Create("sp2-new.plot"); err == nil {
+			t.Errorf("%s: expected a read-only store", name)
+		}
+	}
+}
+
+func TestOSStoreSymlinks(t *testing.T) {
+	dir, farm := t.TempDir(), t.TempDir()
+	path, err := PlotWithOptions(dir, 1, PlotOptions{
+		Format:     FormatSeed,
+		Scheme:     SchemeEd25519,
+		HashParams: &HashParams{Algorithm: HashSHAKE256, Salt: defaultSalt},
+	})
+	if err != nil {
+		t.Fatalf("Failed to plot: %v", err)
+	}
+	link := filepath.Join(farm, filepath.Base(path))
+	if err := os.Symlink(path, link); err != nil {
+		t.Skipf("Can't create symlinks: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(farm, "missing.plot"), filepath.Join(farm, "sp2-dangling.plot")); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := OSStore{}.List(farm)
+	if err != nil || len(names) != 1 || names[0] != link {
+		t.Fatalf("Expected only the linked plot to be listed, got %v: %v", names, err)
+	}
+	if pc, err := LoadPlots([]string{farm}, false); err != nil || len(pc.Plots) != 1 {
+		t.Errorf("Failed to load a linked plot: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2025 Caprica LLC
+
+package storageproof
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+)
+
+const (
+	// writeBufferSize is how much key data is written at once.
+	writeBufferSize = 1 << 20
+	// directIOAlignment is the buffer, offset and size alignment direct I/O
+	// requires on common filesystems.
+	directIOAlignment = 4096
+)
+
+// plotWriter writes a plot sequentially. Key offsets are computed from the
+// header, so key data is streamed through a large buffer straight after the
+// space for the header and table, which are written in one block at the end
+// once every hash, and so the plot ID, is known.
+type plotWriter struct {
+	store PlotStore
+	path  string
+	file  PlotFileWriter
+	// direct holds the key data when the file was reopened for direct I/O
+	direct *os.File
+	buf    interface {
+		io.Writer
+		Flush() error
+	}
+	h       *Header
+	entries []KeyEntry
+	root    merkleBuilder
+	n       uint32 // keys written
+	next    uint64 // offset of the next key
+	size    uint64
+}
+
+func newPlotWriter(store PlotStore, path string, h *Header, opts PlotOptions) (*plotWriter, error) {
+	file, err := store.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDataStart := uint64(h.Size()) + uint64(h.NumKeys)*KeyEntrySize
+	w := &plotWriter{
+		store:   store,
+		path:    path,
+		file:    file,
+		h:       h,
+		entries: make([]KeyEntry, h.NumKeys),
+		next:    keyDataStart,
+		size:    plotFileSize(h),
+	}
+
+	// Preallocation and direct I/O need a file in the local filesystem
+	if osFile, ok := file.(*os.File); ok {
+		if opts.Preallocate {
+			if err := preallocate(osFile, w.size); err != nil && !errors.Is(err, errors.ErrUnsupported) {
+				w.abort()
+				return nil, err
+			}
+		}
+
+		if opts.DirectIO {
+			if direct, err := openDirect(path); err == nil {
+				// Direct writes must start on an aligned offset, so the first
+				// block is padded with the zeros of the table it overlaps
+				start := keyDataStart &^ (directIOAlignment - 1)
+				if _, err := direct.Seek(int64(start), io.SeekStart); err != nil {
+					_ = direct.Close()
+					w.abort()
+					return nil, err
+				}
+				aw := newAlignedWriter(direct)
+				aw.n = int(keyDataStart - start)
+				w.direct, w.buf = direct, aw
+				return w, nil
+			}
+		}
+	}
+
+	w.buf = bufio.NewWriterSize(io.NewOffsetWriter(file, int64(keyDataStart)), writeBufferSize)
+	return w, nil
+}
+
+// add writes the next key's data and records its hash.
+func (w *plotWriter) add(keyData []byte, hash [32]byte) error {
+	if w.n == w.h.NumKeys {
+		return errors.New("too many keys for plot")
+	}
+	if len(keyData) != w.h.keyDataSize() {
+		return fmt.Errorf("key data must be %d bytes", w.h.keyDataSize())
+	}
+	if _, err := w.buf.Write(keyData); err != nil {
+		return err
+	}
+	w.entries[w.n] = KeyEntry{Offset: w.next, Hash: hash}
+	w.root.add(hash)
+	w.n++
+	w.next += uint64(len(keyData))
+	return nil
+}
+
+// close writes the header and table and closes the file.
+func (w *plotWriter) close() error {
+	if w.n != w.h.NumKeys {
+		return fmt.Errorf("plot has %d of %d keys", w.n, w.h.NumKeys)
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if w.direct != nil {
+		if err := w.direct.Close(); err != nil {
+			return err
+		}
+		w.direct = nil
+		// Drop the padding of the last direct write
+		if err := w.file.(*os.File).Truncate(int64(w.size)); err != nil {
+			return err
+		}
+	}
+
+	w.h.ID = w.root.root()
+	block, err := w.h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	block = append(block, make([]byte, len(w.entries)*KeyEntrySize)...)
+	table := block[w.h.Size():]
+	for i, ke := range w.entries {
+		b, err := ke.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		copy(table[i*KeyEntrySize:], b)
+	}
+	if _, err := w.file.WriteAt(block, 0); err != nil {
+		return err
+	}
+	return w.file.Close()
+}
+
+// abort closes and removes an unfinished plot.
+func (w *plotWriter) abort() {
+	if w.direct != nil {
+		_ = w.direct.Close()
+	}
+	_ = w.file.Close()
+	_ = w.store.Remove(w.path)
+}
+
+// alignedWriter buffers writes into aligned blocks for direct I/O, padding
+// the last block with zeros.
+type alignedWriter struct {
+	w   io.Writer
+	buf []byte
+	n   int
+}
+
+func newAlignedWriter(w io.Writer) *alignedWriter {
+	raw := make([]byte, writeBufferSize+directIOAlignment)
+	skip := 0
+	if rem := int(uintptr(unsafe.Pointer(&raw[0])) % directIOAlignment); rem != 0 {
+		skip = directIOAlignment - rem
+	}
+	return &alignedWriter{w: w, buf: raw[skip : skip+writeBufferSize]}
+}
+
+func (a *alignedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		c := copy(a.buf[a.n:], p)
+		a.n += c
+		p = p[c:]
+		written += c
+		if a.n == len(a.buf) {
+			if _, err := a.w.Write(a.buf); err != nil {
+				return written, err
+			}
+			a.n = 0
+		}
+	}
+	return written, nil
+}
+
+func (a *alignedWriter) Flush() error {
+	if a.n == 0 {
+		return nil
+	}
+	padded := (a.n + directIOAlignment - 1) &^ (directIOAlignment - 1)
+	clear(a.buf[a.n:padded])
+	_, err := a.w.Write(a.buf[:padded])
+	a.n = 0
+	return err
+}